@@ -81,3 +81,56 @@ func BenchmarkLoadOrStoreLazyRandomSingle(b *testing.B) {
 		m.LoadOrStoreLazy(fastrand.Int(), func() int { return 1 })
 	}
 }
+
+func BenchmarkStoreSortedSingle(b *testing.B) {
+	keys := make([]int, 1024)
+	for i := range keys {
+		keys[i] = i
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(keys) {
+		m := NewInt[int]()
+		m.StoreSorted(keys, keys)
+	}
+}
+
+func BenchmarkStoreSequentialSingle(b *testing.B) {
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 1024 {
+		m := NewInt[int]()
+		for j := 0; j < 1024; j++ {
+			m.Store(j, j)
+		}
+	}
+}
+
+func BenchmarkLoadManySingle(b *testing.B) {
+	m := NewInt[int]()
+	for i := 0; i < 1000000; i++ {
+		m.Store(i, i)
+	}
+	keys := make([]int, 256)
+	out, found := make([]int, len(keys)), make([]bool, len(keys))
+	b.ResetTimer()
+	for i := 0; i < b.N; i += len(keys) {
+		start := fastrand.Intn(1000000 - 4*len(keys))
+		for j := range keys {
+			keys[j] = start + j*4
+		}
+		m.LoadMany(keys, out, found)
+	}
+}
+
+func BenchmarkLoadSingle(b *testing.B) {
+	m := NewInt[int]()
+	for i := 0; i < 1000000; i++ {
+		m.Store(i, i)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i += 256 {
+		start := fastrand.Intn(1000000 - 4*256)
+		for j := 0; j < 256; j++ {
+			m.Load(start + j*4)
+		}
+	}
+}
@@ -0,0 +1,113 @@
+package skipmap
+
+// NewCounter returns an empty counter map in ascending order.
+func NewCounter[keyT ordered]() *OrderedCounterMap[keyT] {
+	s := new(OrderedCounterMap[keyT])
+	s.init()
+	return s
+}
+
+// NewCounterDesc returns an empty counter map in descending order.
+func NewCounterDesc[keyT ordered]() *OrderedCounterMapDesc[keyT] {
+	s := new(OrderedCounterMapDesc[keyT])
+	s.init()
+	return s
+}
+
+// NewStringCounter returns an empty counter map in ascending order.
+func NewStringCounter() *StringCounterMap {
+	s := new(StringCounterMap)
+	s.init()
+	return s
+}
+
+// NewStringCounterDesc returns an empty counter map in descending order.
+func NewStringCounterDesc() *StringCounterMapDesc {
+	s := new(StringCounterMapDesc)
+	s.init()
+	return s
+}
+
+// NewIntCounter returns an empty counter map in ascending order.
+func NewIntCounter() *IntCounterMap {
+	s := new(IntCounterMap)
+	s.init()
+	return s
+}
+
+// NewIntCounterDesc returns an empty counter map in descending order.
+func NewIntCounterDesc() *IntCounterMapDesc {
+	s := new(IntCounterMapDesc)
+	s.init()
+	return s
+}
+
+// NewInt64Counter returns an empty counter map in ascending order.
+func NewInt64Counter() *Int64CounterMap {
+	s := new(Int64CounterMap)
+	s.init()
+	return s
+}
+
+// NewInt64CounterDesc returns an empty counter map in descending order.
+func NewInt64CounterDesc() *Int64CounterMapDesc {
+	s := new(Int64CounterMapDesc)
+	s.init()
+	return s
+}
+
+// NewInt32Counter returns an empty counter map in ascending order.
+func NewInt32Counter() *Int32CounterMap {
+	s := new(Int32CounterMap)
+	s.init()
+	return s
+}
+
+// NewInt32CounterDesc returns an empty counter map in descending order.
+func NewInt32CounterDesc() *Int32CounterMapDesc {
+	s := new(Int32CounterMapDesc)
+	s.init()
+	return s
+}
+
+// NewUint64Counter returns an empty counter map in ascending order.
+func NewUint64Counter() *Uint64CounterMap {
+	s := new(Uint64CounterMap)
+	s.init()
+	return s
+}
+
+// NewUint64CounterDesc returns an empty counter map in descending order.
+func NewUint64CounterDesc() *Uint64CounterMapDesc {
+	s := new(Uint64CounterMapDesc)
+	s.init()
+	return s
+}
+
+// NewUint32Counter returns an empty counter map in ascending order.
+func NewUint32Counter() *Uint32CounterMap {
+	s := new(Uint32CounterMap)
+	s.init()
+	return s
+}
+
+// NewUint32CounterDesc returns an empty counter map in descending order.
+func NewUint32CounterDesc() *Uint32CounterMapDesc {
+	s := new(Uint32CounterMapDesc)
+	s.init()
+	return s
+}
+
+// NewUintCounter returns an empty counter map in ascending order.
+func NewUintCounter() *UintCounterMap {
+	s := new(UintCounterMap)
+	s.init()
+	return s
+}
+
+// NewUintCounterDesc returns an empty counter map in descending order.
+func NewUintCounterDesc() *UintCounterMapDesc {
+	s := new(UintCounterMapDesc)
+	s.init()
+	return s
+}
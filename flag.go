@@ -1,10 +1,14 @@
 package skipmap
 
-import "sync/atomic"
+import (
+	"runtime"
+	"sync/atomic"
+)
 
 const (
 	fullyLinked = 1 << iota
 	marked
+	pending // the lock-free writers of the node wait for it, see markIfSame and Update
 )
 
 // concurrent-safe bitflag.
@@ -50,3 +54,31 @@ func (f *bitflag) Get(flag uint32) bool {
 func (f *bitflag) MGet(check, expect uint32) bool {
 	return (atomic.LoadUint32(&f.data) & check) == expect
 }
+
+// MGetWait is like MGet, but it waits until the pending flag is cleared by another goroutine first.
+func (f *bitflag) MGetWait(check, expect uint32) bool {
+	data := atomic.LoadUint32(&f.data)
+	if data&pending != 0 {
+		data = f.waitPending()
+	}
+	return data&check == expect
+}
+
+// Wait waits until the flag is cleared by another goroutine.
+func (f *bitflag) Wait(flag uint32) {
+	for f.Get(flag) {
+		runtime.Gosched()
+	}
+}
+
+// waitPending is the slow path of MGetWait, it is not inlined to keep MGetWait inlinable.
+//
+//go:noinline
+func (f *bitflag) waitPending() uint32 {
+	for {
+		runtime.Gosched()
+		if data := atomic.LoadUint32(&f.data); data&pending == 0 {
+			return data
+		}
+	}
+}
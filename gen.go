@@ -61,7 +61,7 @@ func main() {
 		Package:         "skipmap",
 		Name:            "ordered",
 		Path:            "gen_ordered.go",
-		Imports:         "\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
+		Imports:         "\"sort\"\n\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
 		KeyType:         "keyT",
 		ValueType:       "valueT",
 		TypeArgument:    "[keyT, valueT]",
@@ -97,7 +97,7 @@ func main() {
 		Package:         "skipmap",
 		Name:            "func",
 		Path:            "gen_func.go",
-		Imports:         "\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
+		Imports:         "\"sort\"\n\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
 		KeyType:         "keyT",
 		ValueType:       "valueT",
 		TypeArgument:    "[keyT, valueT]",
@@ -124,7 +124,7 @@ func main() {
 			Package:         "skipmap",
 			Name:            "{{TypeLow}}",
 			Path:            "gen_{{TypeLow}}.go",
-			Imports:         "\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
+			Imports:         "\"sort\"\n\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
 			KeyType:         "{{TypeLow}}",
 			ValueType:       "valueT",
 			TypeArgument:    "[valueT]",
@@ -145,7 +145,7 @@ func main() {
 			Package:         "skipmap",
 			Name:            "{{TypeLow}}Desc",
 			Path:            "gen_{{TypeLow}}desc.go",
-			Imports:         "\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
+			Imports:         "\"sort\"\n\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n",
 			KeyType:         "{{TypeLow}}",
 			ValueType:       "valueT",
 			TypeArgument:    "[valueT]",
@@ -178,6 +178,8 @@ func main() {
 		generate(baseType)
 		generate(baseTypeDesc)
 	}
+	generateSeq("gen_seq.go")
+	generateCounter("gen_counter.go")
 }
 
 // generate generates the code for variant `v` into a file named by `v.Path`.
@@ -206,6 +208,57 @@ func generate(v *Variant) {
 	if err := os.WriteFile(v.Path, formatted, 0644); err != nil {
 		log.Fatal("WriteFile:", err)
 	}
+
+	// The iterators of all variants are collected into a single file, see generateSeq.
+	err = tmpl.ExecuteTemplate(&seqCode, "seq", v)
+	if err != nil {
+		log.Fatal("template Execute seq:", err)
+	}
+
+	// So are the counter maps, see generateCounter.
+	err = tmpl.ExecuteTemplate(&counterCode, "counter", v)
+	if err != nil {
+		log.Fatal("template Execute counter:", err)
+	}
+}
+
+var (
+	// seqCode is the code generated by the "seq" template for all variants.
+	seqCode bytes.Buffer
+	// counterCode is the code generated by the "counter" template for all variants.
+	counterCode bytes.Buffer
+)
+
+// generateSeq writes the code in seqCode into a file named by path.
+// The iter package is only available since Go 1.23, the file is guarded by
+// a build constraint so that the module still supports older versions.
+func generateSeq(path string) {
+	generateMerged(path, "//go:build go1.23\n\n", "import \"iter\"\n", &seqCode)
+}
+
+// generateCounter writes the code in counterCode into a file named by path.
+func generateCounter(path string) {
+	generateMerged(path, "", "import (\n\"sync\"\n\"sync/atomic\"\n\"unsafe\"\n)\n", &counterCode)
+}
+
+// generateMerged writes the code collected from all variants into a file named by path.
+func generateMerged(path, constraint, imports string, code *bytes.Buffer) {
+	var out bytes.Buffer
+	out.WriteString("// Code generated by gen.go; DO NOT EDIT.\n\n")
+	out.WriteString(constraint)
+	out.WriteString("package skipmap\n\n")
+	out.WriteString(imports)
+	out.Write(code.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		println(string(out.Bytes()))
+		log.Fatal("format:", err)
+	}
+
+	if err := os.WriteFile(path, formatted, 0644); err != nil {
+		log.Fatal("WriteFile:", err)
+	}
 }
 
 //go:embed skipmap.tpl
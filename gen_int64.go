@@ -3,6 +3,7 @@
 package skipmap
 
 import (
+	"sort"
 	"sync"
 	"sync/atomic"
 	"unsafe"
@@ -10,9 +11,20 @@ import (
 
 // Int64Map represents a map based on skip list.
 type Int64Map[valueT any] struct {
+	list  unsafe.Pointer // *int64list, replaced by Clear
+	index *sync.RWMutex  // non-nil if the span counts are maintained, see WithIndex
+	snap  *snapshots     // non-nil if the snapshots are supported, see WithSnapshot
+	txn   bool           // the transactions are supported, see WithTxn
+	calls callMap[int64, valueT]
+}
+
+// int64list is the skip list of a skipmap. Every operation loads the list
+// once and works on it, so the list can be replaced by an empty one atomically.
+type int64list[valueT any] struct {
 	length       int64
 	highestLevel uint64 // highest level for now
 	header       *int64node[valueT]
+	dead         unsafe.Pointer // *int64list of the deleted nodes kept for the snapshots, see retire
 }
 
 type int64node[valueT any] struct {
@@ -36,6 +48,769 @@ func newInt64Node[valueT any](key int64, value valueT, level int) *int64node[val
 	return node
 }
 
+// int64xnode is the node with extra fields used by the optional features,
+// all the nodes of a skipmap created with these features are allocated as it.
+type int64xnode[valueT any] struct {
+	int64node[valueT]
+	span []int // span[i] is the number of nodes from this node to next[i] at level 0
+
+	// The timestamps when the node is created and deleted (0 if not), see WithSnapshot.
+	created uint64
+	deleted uint64
+}
+
+// init initializes an empty skipmap with the given options.
+func (s *Int64Map[valueT]) init(opts []Option) {
+	cfg := newConfig(opts)
+	if cfg.index {
+		s.index = new(sync.RWMutex)
+	}
+	if cfg.snapshot {
+		s.snap = newSnapshots()
+	}
+	s.txn = cfg.txn
+	s.list = unsafe.Pointer(s.newList())
+}
+
+// newList returns an empty list.
+func (s *Int64Map[valueT]) newList() *int64list[valueT] {
+	var (
+		t1 int64
+		t2 valueT
+	)
+	l := &int64list[valueT]{
+		header:       s.newNode(t1, t2, maxLevel),
+		highestLevel: defaultHighestLevel,
+	}
+	l.header.flags.SetTrue(fullyLinked)
+	return l
+}
+
+// initSorted builds the list of an empty skipmap from the sorted keys and values in one pass,
+// it returns ErrUnsorted and leaves the skipmap empty if the keys are not strictly ordered.
+func (s *Int64Map[valueT]) initSorted(keys []int64, values []valueT) error {
+	if len(keys) != len(values) {
+		panic("skipmap: keys and values have different lengths")
+	}
+	if !s.isSorted(keys) {
+		return ErrUnsorted
+	}
+	b := s.newBuilder(s.load())
+	for i, key := range keys {
+		b.append(key, values[i], randomLevel())
+	}
+	b.finish()
+	return nil
+}
+
+// int64builder builds the list of a skipmap which is not shared yet by appending the nodes in order,
+// so the nodes are linked without locks and atomic operations.
+type int64builder[valueT any] struct {
+	s     *Int64Map[valueT]
+	l     *int64list[valueT]
+	tails [maxLevel]*int64node[valueT] // tails[i] is the last node at level i
+	ranks [maxLevel]int                // ranks[i] is the rank of tails[i], the header is 0
+	n     int                          // the number of nodes appended
+}
+
+// newBuilder returns a builder of the empty list l.
+func (s *Int64Map[valueT]) newBuilder(l *int64list[valueT]) *int64builder[valueT] {
+	b := &int64builder[valueT]{s: s, l: l}
+	for i := range b.tails {
+		b.tails[i] = l.header
+	}
+	return b
+}
+
+// append appends a node with the given level, the key must be greater than the last one.
+func (b *int64builder[valueT]) append(key int64, value valueT, level int) {
+	nn := b.s.newNode(key, value, level)
+	b.n++
+	for i := 0; i < level; i++ {
+		b.tails[i].storeNext(i, nn)
+		if b.s.index != nil {
+			b.tails[i].spans()[i] = b.n - b.ranks[i]
+			b.ranks[i] = b.n
+		}
+		b.tails[i] = nn
+	}
+	nn.flags.SetTrue(fullyLinked)
+	if uint64(level) > b.l.highestLevel {
+		b.l.highestLevel = uint64(level)
+	}
+}
+
+// finish sets the length of the list.
+func (b *int64builder[valueT]) finish() {
+	b.l.length = int64(b.n)
+}
+
+// Clone returns a copy of the skipmap with the same options, the copy is independent of the skipmap,
+// i.e. the later writes to either one are not reflected in the other. The copy is built in one pass
+// over the keys, and each node keeps its level, so it costs O(n) without taking any node's lock.
+//
+// Like Range, Clone is not a snapshot: every key present during the whole call is copied, while
+// a concurrent write may or may not be reflected, and each key is copied with the value loaded when
+// it is visited. If the skipmap is created with WithIndex, Clone holds the index lock in read mode,
+// so the structural writers (e.g. Store and Delete) wait for it and the copy is a snapshot, except
+// for the values replaced by CompareAndSwap and CompareAndSwapFunc, which do not take the lock.
+func (s *Int64Map[valueT]) Clone() *Int64Map[valueT] {
+	c := new(Int64Map[valueT])
+	if s.index != nil {
+		c.index = new(sync.RWMutex)
+		s.index.RLock()
+		defer s.index.RUnlock()
+	}
+	if s.snap != nil {
+		c.snap = newSnapshots()
+	}
+	c.txn = s.txn
+	c.list = unsafe.Pointer(c.newList())
+	b := c.newBuilder(c.load())
+	for x := s.firstNode(s.load()); x != nil; x = s.nextValid(x.atomicLoadNext(0)) {
+		b.append(x.key, x.loadVal(), int(x.level))
+	}
+	b.finish()
+	return c
+}
+
+// Int64Snapshot is a read-only view of a skipmap at the time it is taken, see Snapshot.
+type Int64Snapshot[valueT any] struct {
+	s      *Int64Map[valueT]
+	l      *int64list[valueT]
+	ts     uint64
+	length int
+	closed bool // protected by s.snap.mu
+}
+
+// Snapshot returns a read-only view of the skipmap at this time, the later writes are not reflected in it.
+// The skipmap must be created with WithSnapshot, or it panics.
+//
+// Snapshot costs O(1), it waits for the concurrent writers to finish, and stops the new writers
+// until it returns, so it must not be called by the functions passed to the writers such as Compute.
+// The old values and the deleted keys are kept for the snapshot until it is closed, so Close must be
+// called when it is no longer used.
+func (s *Int64Map[valueT]) Snapshot() *Int64Snapshot[valueT] {
+	if s.snap == nil {
+		panic("skipmap: Snapshot requires WithSnapshot")
+	}
+	sn := s.snap
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	l := s.load()
+	snapshot := &Int64Snapshot[valueT]{s: s, l: l, ts: sn.clock, length: int(atomic.LoadInt64(&l.length))}
+	sn.live = append(sn.live, sn.clock)
+	sn.clock++
+	return snapshot
+}
+
+// Load returns the value stored in the skipmap for a key when the snapshot was taken.
+// The ok result indicates whether value was found.
+func (ss *Int64Snapshot[valueT]) Load(key int64) (value valueT, ok bool) {
+	s, l := ss.s, ss.l
+	x := l.header
+	var nex *int64node[valueT]
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex = x.atomicLoadNext(i)
+		for nex != nil && (nex.key < key) {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+	}
+	if nex != nil && nex.key == key {
+		if value, ok = s.loadAt(nex, ss.ts); ok {
+			return value, ok
+		}
+	}
+	// The node may be unlinked before it is loaded, see Range.
+	if dead := (*int64list[valueT])(atomic.LoadPointer(&l.dead)); dead != nil {
+		for g := s.ceilingNode(dead, key); g != nil && g.key == key; g = s.nextValid(g.atomicLoadNext(0)) {
+			if value, ok = s.loadAt((*int64node[valueT])(g.value), ss.ts); ok {
+				return value, ok
+			}
+		}
+	}
+	return value, false
+}
+
+// Range calls f sequentially for each key and value present in the skipmap when the snapshot was taken.
+// If f returns false, range stops the iteration.
+func (ss *Int64Snapshot[valueT]) Range(f func(key int64, value valueT) bool) {
+	s, l := ss.s, ss.l
+	x := l.header
+	for {
+		nex := x.atomicLoadNext(0)
+		// A deleted node between x and nex is unlinked before nex is loaded, and it is retired before
+		// it is unlinked, so it can be found in the deleted nodes now if the snapshot can see it.
+		if dead := (*int64list[valueT])(atomic.LoadPointer(&l.dead)); dead != nil {
+			var g *int64node[valueT]
+			if x == l.header {
+				g = s.firstNode(dead)
+			} else {
+				g = s.higherNode(dead, x.key)
+			}
+			for ; g != nil && (nex == nil || !(nex.key < g.key)); g = s.nextValid(g.atomicLoadNext(0)) {
+				if d := (*int64node[valueT])(g.value); d != nex {
+					if value, ok := s.loadAt(d, ss.ts); ok && !f(d.key, value) {
+						return
+					}
+				}
+			}
+		}
+		if nex == nil {
+			return
+		}
+		if value, ok := s.loadAt(nex, ss.ts); ok && !f(nex.key, value) {
+			return
+		}
+		x = nex
+	}
+}
+
+// Len returns the number of keys in the skipmap when the snapshot was taken.
+func (ss *Int64Snapshot[valueT]) Len() int {
+	return ss.length
+}
+
+// Close releases the snapshot, the old values and the deleted keys only needed by it are dropped.
+// The snapshot must not be used after Close, and closing it again does nothing.
+func (ss *Int64Snapshot[valueT]) Close() {
+	s, sn := ss.s, ss.s.snap
+	sn.mu.Lock()
+	defer sn.mu.Unlock()
+	if ss.closed {
+		return
+	}
+	ss.closed = true
+	i := sort.Search(len(sn.live), func(i int) bool { return sn.live[i] >= ss.ts })
+	sn.live = append(sn.live[:i], sn.live[i+1:]...)
+	s.collectDead(ss.l)
+	if l := s.load(); l != ss.l {
+		s.collectDead(l) // the skipmap has been cleared
+	}
+}
+
+// collectDead drops the deleted nodes of l which are no longer needed by the live snapshots.
+// The caller must hold s.snap.mu in write mode, so no writer is running.
+func (s *Int64Map[valueT]) collectDead(l *int64list[valueT]) {
+	dead := (*int64list[valueT])(atomic.LoadPointer(&l.dead))
+	if dead == nil {
+		return
+	}
+	var kept *int64list[valueT]
+	for g := s.firstNode(dead); g != nil; g = s.nextValid(g.atomicLoadNext(0)) {
+		n := (*int64node[valueT])(g.value)
+		if x := n.xnode(); s.snap.needed(x.created, atomic.LoadUint64(&x.deleted)) {
+			if kept == nil {
+				kept = s.newDeadList()
+			}
+			s.insertDead(kept, n)
+		}
+	}
+	atomic.StorePointer(&l.dead, unsafe.Pointer(kept))
+}
+
+// loadAt returns the value of the node seen by the snapshot taken at ts, the skipmap must be created
+// with WithSnapshot. The ok result is false if the snapshot can not see the node.
+func (s *Int64Map[valueT]) loadAt(n *int64node[valueT], ts uint64) (value valueT, ok bool) {
+	x := n.xnode()
+	if !visibleAt(x.created, atomic.LoadUint64(&x.deleted), ts) {
+		return value, false
+	}
+	return loadVersion[valueT](atomic.LoadPointer(&n.value), ts)
+}
+
+// retire stamps the node which is marked by this process as deleted, and keeps it in l.dead if any
+// live snapshot can see it. It must be called before the node is unlinked, see Range of the snapshots.
+func (s *Int64Map[valueT]) retire(l *int64list[valueT], n *int64node[valueT]) {
+	sn := s.snap
+	x := n.xnode()
+	atomic.StoreUint64(&x.deleted, sn.clock)
+	if !sn.needed(x.created, sn.clock) {
+		return
+	}
+	sn.deadMu.Lock()
+	defer sn.deadMu.Unlock()
+	dead := (*int64list[valueT])(atomic.LoadPointer(&l.dead))
+	if dead == nil {
+		dead = s.newDeadList()
+		atomic.StorePointer(&l.dead, unsafe.Pointer(dead))
+	}
+	s.insertDead(dead, n)
+}
+
+// newDeadList returns an empty list of the deleted nodes, which contains the ghost nodes whose
+// values point to the deleted nodes, sorted by the keys.
+func (s *Int64Map[valueT]) newDeadList() *int64list[valueT] {
+	return &int64list[valueT]{header: newInt64Node[valueT](*new(int64), *new(valueT), maxLevel), highestLevel: 1}
+}
+
+// insertDead inserts a ghost node of n into l after the ones with the same key. The caller must hold
+// s.snap.deadMu, or s.snap.mu in write mode, while the readers are lock-free.
+func (s *Int64Map[valueT]) insertDead(l *int64list[valueT], n *int64node[valueT]) {
+	level := randomLevel()
+	if uint64(level) > l.highestLevel {
+		atomic.StoreUint64(&l.highestLevel, uint64(level))
+	}
+	var (
+		preds [maxLevel]*int64node[valueT]
+		x     = l.header
+	)
+	for i := int(l.highestLevel) - 1; i >= 0; i-- {
+		nex := x.loadNext(i)
+		for nex != nil && !(n.key < nex.key) {
+			x = nex
+			nex = x.loadNext(i)
+		}
+		preds[i] = x
+	}
+	g := &int64node[valueT]{key: n.key, value: unsafe.Pointer(n), level: uint32(level)}
+	if level > op1 {
+		g.next.extra = new([op2]unsafe.Pointer)
+	}
+	g.flags.SetTrue(fullyLinked)
+	for i := 0; i < level; i++ {
+		g.storeNext(i, preds[i].loadNext(i))
+		preds[i].atomicStoreNext(i, g)
+	}
+	atomic.AddInt64(&l.length, 1)
+}
+
+// Int64Txn is a transaction of a skipmap, see Update.
+type Int64Txn[valueT any] struct {
+	s   *Int64Map[valueT]
+	l   *int64list[valueT]
+	ops []int64txnOp[valueT] // sorted by the keys
+}
+
+// int64txnOp is the reads and the writes of a key in a transaction.
+type int64txnOp[valueT any] struct {
+	key   int64
+	read  bool               // the key is read from the skipmap, it is validated when committing
+	node  *int64node[valueT] // the node read, nil if the key is absent
+	value unsafe.Pointer     // the value of the node read
+	op    Op                 // OpStore or OpDelete if the key is written
+	new   valueT             // the value to store
+
+	locked   *int64node[valueT] // the node locked by commit, with the pending flag set
+	inserted bool               // the locked node is inserted by commit
+}
+
+// Update calls f with a transaction, and commits the keys written by f with Put and Delete
+// atomically: the readers see all of them or none of them. If f returns an error, the writes
+// are discarded and Update returns the error.
+//
+// The values read by Get are validated when committing, f is called again if any of them
+// has been changed concurrently, so f may be called more than once. The transaction must not
+// be used after f returns, or by other goroutines.
+//
+// The skipmap must be created with WithTxn, or it panics. The commit locks the nodes of the written
+// keys in order, and the readers and the writers of these keys wait until it is finished, so they
+// are not wait-free during the commit.
+func (s *Int64Map[valueT]) Update(f func(tx *Int64Txn[valueT]) error) error {
+	if !s.txn {
+		panic("skipmap: Update requires WithTxn")
+	}
+	for {
+		tx := &Int64Txn[valueT]{s: s, l: s.load()}
+		if err := f(tx); err != nil {
+			return err
+		}
+		if s.commit(tx) {
+			return nil
+		}
+	}
+}
+
+// Get returns the value for a key in the transaction, i.e. the value written by the transaction
+// or the value stored in the skipmap. The ok result indicates whether value was found.
+func (tx *Int64Txn[valueT]) Get(key int64) (value valueT, ok bool) {
+	op := tx.s.op(tx, key)
+	switch {
+	case op.op == OpStore:
+		return op.new, true
+	case op.op == OpDelete:
+		return value, false
+	case !op.read:
+		tx.read(op)
+	}
+	if op.node == nil {
+		return value, false
+	}
+	return *(*valueT)(op.value), true
+}
+
+// Put sets the value for a key in the transaction.
+func (tx *Int64Txn[valueT]) Put(key int64, value valueT) {
+	op := tx.s.op(tx, key)
+	op.op, op.new = OpStore, value
+}
+
+// Delete deletes the value for a key in the transaction.
+func (tx *Int64Txn[valueT]) Delete(key int64) {
+	op := tx.s.op(tx, key)
+	var zero valueT
+	op.op, op.new = OpDelete, zero
+}
+
+// op returns the operation of the key in the transaction, it is added if not present.
+func (s *Int64Map[valueT]) op(tx *Int64Txn[valueT], key int64) *int64txnOp[valueT] {
+	i := sort.Search(len(tx.ops), func(i int) bool { return !(tx.ops[i].key < key) })
+	if i < len(tx.ops) && tx.ops[i].key == key {
+		return &tx.ops[i]
+	}
+	tx.ops = append(tx.ops, int64txnOp[valueT]{})
+	copy(tx.ops[i+1:], tx.ops[i:])
+	tx.ops[i] = int64txnOp[valueT]{key: key}
+	return &tx.ops[i]
+}
+
+// read reads the key from the skipmap, it waits for the transaction committing the node.
+func (tx *Int64Txn[valueT]) read(op *int64txnOp[valueT]) {
+	s := tx.s
+	op.read = true
+	x := s.ceilingNode(tx.l, op.key)
+	if x == nil || !(x.key == op.key) {
+		return
+	}
+	for {
+		// Load the value before checking the flags, see swapVal.
+		p := atomic.LoadPointer(&x.value)
+		if x.flags.Get(pending) {
+			x.flags.Wait(pending)
+			continue
+		}
+		if !x.flags.Get(marked) {
+			op.node, op.value = x, p
+		}
+		return
+	}
+}
+
+// commit applies the writes of the transaction if the keys read are not changed,
+// it returns false if the transaction must run again.
+//
+// The nodes of the written keys are locked in descending order like the other writers, and
+// the pending flags are set on them; the absent keys are inserted as the pending nodes which
+// are not fully linked. Once all of them are pending, the readers and the writers of these keys
+// wait for the commit, so the keys read are validated, then the writes are applied and the
+// pending flags are cleared at last. The keys are compared while holding the locks, so the
+// transaction is aborted by a deferred call if the comparison panics.
+func (s *Int64Map[valueT]) commit(tx *Int64Txn[valueT]) bool {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := tx.l
+	released := false
+	defer func() {
+		if !released {
+			s.abort(l, tx)
+		}
+	}()
+	var preds, succs [maxLevel]*int64node[valueT]
+	for i := len(tx.ops) - 1; i >= 0; i-- {
+		op := &tx.ops[i]
+		if op.op == OpKeep {
+			continue
+		}
+		level := s.randomlevel(l)
+		for {
+			nodeFound := s.findNode(l, op.key, &preds, &succs)
+			if nodeFound != nil {
+				// Wait for the node to be fully linked or removed, see Compute.
+				if !s.valid(nodeFound) {
+					continue
+				}
+				nodeFound.mu.Lock()
+				if nodeFound.flags.Get(marked) {
+					nodeFound.mu.Unlock()
+					continue
+				}
+				nodeFound.flags.SetTrue(pending)
+				op.locked = nodeFound
+				break
+			}
+			if op.op == OpDelete {
+				break // the key is absent, see validate
+			}
+			if nn := s.insertPending(l, op.key, op.new, level, &preds, &succs); nn != nil {
+				op.locked, op.inserted = nn, true
+				if s.index != nil {
+					s.indexInsert(l, nn)
+				}
+				break
+			}
+		}
+	}
+
+	valid := s.load() == l // the skipmap is not cleared
+	for i := 0; valid && i < len(tx.ops); i++ {
+		valid = s.validate(l, &tx.ops[i])
+	}
+	if !valid {
+		released = true
+		s.abort(l, tx)
+		return false
+	}
+	var delta int64
+	for i := range tx.ops {
+		op := &tx.ops[i]
+		x := op.locked
+		switch {
+		case x == nil:
+		case op.op == OpDelete:
+			x.flags.SetTrue(marked)
+			delta--
+		case op.inserted:
+			x.flags.SetTrue(fullyLinked)
+			delta++
+		default:
+			atomic.StorePointer(&x.value, s.newVal(op.new, atomic.LoadPointer(&x.value)))
+		}
+	}
+	atomic.AddInt64(&l.length, delta)
+	released = true
+	s.release(l, tx)
+	return true
+}
+
+// insertPending inserts a locked pending node for the key, it returns nil if the preds or succs
+// are changed concurrently. The caller must update the span counts if the skipmap is indexed.
+// (Modified from Store)
+func (s *Int64Map[valueT]) insertPending(l *int64list[valueT], key int64, value valueT, level int, preds, succs *[maxLevel]*int64node[valueT]) *int64node[valueT] {
+	var (
+		highestLocked        = -1 // the highest level being locked by this process
+		valid                = true
+		pred, succ, prevPred *int64node[valueT]
+	)
+	for layer := 0; valid && layer < level; layer++ {
+		pred = preds[layer]   // target node's previous node
+		succ = succs[layer]   // target node's next node
+		if pred != prevPred { // the node in this layer could be locked by previous loop
+			pred.mu.Lock()
+			highestLocked = layer
+			prevPred = pred
+		}
+		valid = !pred.flags.Get(marked) && (succ == nil || !succ.flags.Get(marked)) && pred.loadNext(layer) == succ
+	}
+	if !valid {
+		unlockint64(*preds, highestLocked)
+		return nil
+	}
+	nn := s.newNode(key, value, level)
+	nn.flags.SetTrue(pending)
+	nn.mu.Lock()
+	for layer := 0; layer < level; layer++ {
+		nn.storeNext(layer, succs[layer])
+		preds[layer].atomicStoreNext(layer, nn)
+	}
+	unlockint64(*preds, highestLocked)
+	return nn
+}
+
+// validate reports whether the key read by the transaction is not changed, the written keys
+// must be pending. The values of the nodes read are replaced with new pointers to the same
+// values, so the lock-free writers which have loaded the values before will fail, see swapVal.
+func (s *Int64Map[valueT]) validate(l *int64list[valueT], op *int64txnOp[valueT]) bool {
+	x := op.locked
+	if x == nil {
+		if !op.read && op.op == OpKeep {
+			return true
+		}
+		// The key is read only, or absent when deleting.
+		var preds, succs [maxLevel]*int64node[valueT]
+		x = s.findNode(l, op.key, &preds, &succs)
+		if x != nil && x.flags.Get(marked) {
+			x = nil // no other node for the key until it is removed, see Store
+		}
+		if x != nil && !x.flags.MGet(fullyLinked|pending, fullyLinked) {
+			return false // the node is being inserted or committed
+		}
+		if !op.read {
+			return x == nil
+		}
+		return x == op.node && (x == nil || atomic.LoadPointer(&x.value) == op.value)
+	}
+	if !op.read {
+		return true
+	}
+	if op.inserted {
+		return op.node == nil
+	}
+	return x == op.node && atomic.CompareAndSwapPointer(&x.value, op.value, s.copyVal(op.value))
+}
+
+// abort removes the nodes inserted by the transaction and releases the others.
+func (s *Int64Map[valueT]) abort(l *int64list[valueT], tx *Int64Txn[valueT]) {
+	for i := range tx.ops {
+		if op := &tx.ops[i]; op.inserted {
+			op.locked.flags.SetTrue(marked)
+		}
+	}
+	s.release(l, tx)
+}
+
+// release clears the pending flags of the nodes locked by the transaction and unlocks them,
+// the marked ones are removed in ascending order, so their predecessors are not locked.
+// If a comparison panics while removing one of them, the rest are removed by a deferred call.
+func (s *Int64Map[valueT]) release(l *int64list[valueT], tx *Int64Txn[valueT]) {
+	for i := range tx.ops {
+		if x := tx.ops[i].locked; x != nil {
+			x.flags.SetFalse(pending)
+		}
+	}
+	for i := range tx.ops {
+		if x := tx.ops[i].locked; x != nil && !x.flags.Get(marked) {
+			x.mu.Unlock()
+		}
+	}
+	var (
+		preds, succs [maxLevel]*int64node[valueT]
+		i            int
+	)
+	defer func() {
+		if i == len(tx.ops) {
+			return
+		}
+		// The node being removed is finished by unlinkNode.
+		for i++; i < len(tx.ops); i++ {
+			if x := tx.ops[i].locked; x != nil && x.flags.Get(marked) {
+				s.finishUnlink(l, x, false)
+			}
+		}
+	}()
+	for ; i < len(tx.ops); i++ {
+		if x := tx.ops[i].locked; x != nil && x.flags.Get(marked) {
+			s.unlinkNode(l, x, &preds, &succs)
+		}
+	}
+}
+
+// isSorted reports whether the keys are strictly ordered by the skipmap's order.
+func (s *Int64Map[valueT]) isSorted(keys []int64) bool {
+	for i := 1; i < len(keys); i++ {
+		if !(keys[i-1] < keys[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// load returns the current list of the skipmap.
+func (s *Int64Map[valueT]) load() *int64list[valueT] {
+	return (*int64list[valueT])(atomic.LoadPointer(&s.list))
+}
+
+// newNode returns a new node, which is allocated as int64xnode if any optional feature is enabled.
+func (s *Int64Map[valueT]) newNode(key int64, value valueT, level int) *int64node[valueT] {
+	if s.index == nil && s.snap == nil {
+		return newInt64Node(key, value, level)
+	}
+	x := new(int64xnode[valueT])
+	if s.index != nil {
+		x.span = make([]int, level)
+	}
+	if s.snap != nil {
+		x.created = s.snap.clock
+	}
+	node := &x.int64node
+	node.key = key
+	node.level = uint32(level)
+	node.value = s.newVal(value, nil)
+	if level > op1 {
+		node.next.extra = new([op2]unsafe.Pointer)
+	}
+	return node
+}
+
+// spans returns the span counts of the node, the skipmap must be created with WithIndex.
+func (n *int64node[valueT]) spans() []int {
+	return n.xnode().span
+}
+
+// xnode returns the node as int64xnode, the skipmap must be created with any optional feature.
+func (n *int64node[valueT]) xnode() *int64xnode[valueT] {
+	return (*int64xnode[valueT])(unsafe.Pointer(n))
+}
+
+// valid reports whether the node is fully linked and not marked. If the skipmap is created with WithTxn,
+// it waits for the transaction committing the node first, so the changes of a transaction are seen
+// all or nothing, see Update.
+func (s *Int64Map[valueT]) valid(n *int64node[valueT]) bool {
+	if s.txn {
+		return n.flags.MGetWait(fullyLinked|marked, fullyLinked)
+	}
+	return n.flags.MGet(fullyLinked|marked, fullyLinked)
+}
+
+// newVal returns the pointer to a new value of a node, which replaces the pointer prev (nil for a new node).
+// If the skipmap is created with WithSnapshot, it points to a version of the value, which links the previous
+// versions needed by the live snapshots. The caller must hold s.snap.mu in read mode, or own the skipmap.
+func (s *Int64Map[valueT]) newVal(value valueT, prev unsafe.Pointer) unsafe.Pointer {
+	if s.snap == nil {
+		return unsafe.Pointer(&value)
+	}
+	return unsafe.Pointer(newVersion(value, prev, s.snap.clock, s.snap.horizon()))
+}
+
+// copyVal returns a new pointer to the same value as p, see markIfSame.
+func (s *Int64Map[valueT]) copyVal(p unsafe.Pointer) unsafe.Pointer {
+	if s.snap == nil {
+		value := *(*valueT)(p)
+		return unsafe.Pointer(&value)
+	}
+	v := (*version[valueT])(p)
+	return unsafe.Pointer(&version[valueT]{value: v.value, ts: v.ts, prev: atomic.LoadPointer(&v.prev)})
+}
+
+// markIfSame marks the node if its value is still p, and reports whether it is marked.
+// The caller must hold the node's lock, and the node must not be marked.
+//
+// The lock-free writers (see swapVal) do not take the lock, so the value is replaced with
+// a new pointer to the same value while the pending flag is set: the writers that have loaded p
+// fail, and the ones that load the new pointer wait until the node is marked. If the value has
+// been replaced by them, the node is not marked, so a mark is never cleared once it is set.
+func (s *Int64Map[valueT]) markIfSame(n *int64node[valueT], p unsafe.Pointer) bool {
+	n.flags.SetTrue(pending)
+	ok := atomic.CompareAndSwapPointer(&n.value, p, s.copyVal(p))
+	if ok {
+		n.flags.SetTrue(marked)
+	}
+	n.flags.SetFalse(pending)
+	return ok
+}
+
+// storeVal stores the value of the node, see newVal. It returns false if the node is marked.
+func (s *Int64Map[valueT]) storeVal(n *int64node[valueT], value valueT) bool {
+	_, ok := s.swapVal(n, value)
+	return ok
+}
+
+// swapVal swaps the value of the node and returns the previous one, see newVal.
+// It returns false if the node is marked.
+func (s *Int64Map[valueT]) swapVal(n *int64node[valueT], value valueT) (previous valueT, ok bool) {
+	for {
+		// Load the value before checking the flags, the value is replaced
+		// after setting the pending flag, see markIfSame and commit.
+		p := atomic.LoadPointer(&n.value)
+		if n.flags.Get(pending) {
+			n.flags.Wait(pending)
+			continue
+		}
+		if n.flags.Get(marked) {
+			return previous, false
+		}
+		if atomic.CompareAndSwapPointer(&n.value, p, s.newVal(value, p)) {
+			return *(*valueT)(p), true
+		}
+	}
+}
+
 func (n *int64node[valueT]) storeVal(value valueT) {
 	atomic.StorePointer(&n.value, unsafe.Pointer(&value))
 }
@@ -63,9 +838,9 @@ func (n *int64node[valueT]) atomicStoreNext(i int, node *int64node[valueT]) {
 // findNode takes a key and two maximal-height arrays then searches exactly as in a sequential skipmap.
 // The returned preds and succs always satisfy preds[i] > key >= succs[i].
 // (without fullpath, if find the node will return immediately)
-func (s *Int64Map[valueT]) findNode(key int64, preds *[maxLevel]*int64node[valueT], succs *[maxLevel]*int64node[valueT]) *int64node[valueT] {
-	x := s.header
-	for i := int(atomic.LoadUint64(&s.highestLevel)) - 1; i >= 0; i-- {
+func (s *Int64Map[valueT]) findNode(l *int64list[valueT], key int64, preds *[maxLevel]*int64node[valueT], succs *[maxLevel]*int64node[valueT]) *int64node[valueT] {
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
 		succ := x.atomicLoadNext(i)
 		for succ != nil && (succ.key < key) {
 			x = succ
@@ -84,10 +859,10 @@ func (s *Int64Map[valueT]) findNode(key int64, preds *[maxLevel]*int64node[value
 
 // findNodeDelete takes a key and two maximal-height arrays then searches exactly as in a sequential skip-list.
 // The returned preds and succs always satisfy preds[i] > key >= succs[i].
-func (s *Int64Map[valueT]) findNodeDelete(key int64, preds *[maxLevel]*int64node[valueT], succs *[maxLevel]*int64node[valueT]) int {
+func (s *Int64Map[valueT]) findNodeDelete(l *int64list[valueT], key int64, preds *[maxLevel]*int64node[valueT], succs *[maxLevel]*int64node[valueT]) int {
 	// lFound represents the index of the first layer at which it found a node.
-	lFound, x := -1, s.header
-	for i := int(atomic.LoadUint64(&s.highestLevel)) - 1; i >= 0; i-- {
+	lFound, x := -1, l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
 		succ := x.atomicLoadNext(i)
 		for succ != nil && (succ.key < key) {
 			x = succ
@@ -104,28 +879,57 @@ func (s *Int64Map[valueT]) findNodeDelete(key int64, preds *[maxLevel]*int64node
 	return lFound
 }
 
-func unlockint64[valueT any](preds [maxLevel]*int64node[valueT], highestLevel int) {
-	var prevPred *int64node[valueT]
-	for i := highestLevel; i >= 0; i-- {
-		if preds[i] != prevPred { // the node could be unlocked by previous loop
-			preds[i].mu.Unlock()
-			prevPred = preds[i]
+// findNodeFrom is like findNodeDelete, but the search at each level resumes from preds[i] if it is
+// further than the node reached at the upper level, so a sequence of searches for increasing keys
+// only walks the distance between them. Before the first search, the preds must be empty.
+func (s *Int64Map[valueT]) findNodeFrom(l *int64list[valueT], key int64, preds *[maxLevel]*int64node[valueT], succs *[maxLevel]*int64node[valueT]) {
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		if p := preds[i]; p != nil && p != l.header && (x == l.header || (x.key < p.key)) {
+			x = p
 		}
-	}
-}
+		succ := x.atomicLoadNext(i)
+		for succ != nil && (succ.key < key) {
+			x = succ
+			succ = x.atomicLoadNext(i)
+		}
+		preds[i] = x
+		succs[i] = succ
+	}
+}
+
+func unlockint64[valueT any](preds [maxLevel]*int64node[valueT], highestLevel int) {
+	var prevPred *int64node[valueT]
+	for i := highestLevel; i >= 0; i-- {
+		if preds[i] != prevPred { // the node could be unlocked by previous loop
+			preds[i].mu.Unlock()
+			prevPred = preds[i]
+		}
+	}
+}
 
 // Store sets the value for a key.
 func (s *Int64Map[valueT]) Store(key int64, value valueT) {
-	level := s.randomlevel()
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	level := s.randomlevel(l)
 	var preds, succs [maxLevel]*int64node[valueT]
 	for {
-		nodeFound := s.findNode(key, &preds, &succs)
+		nodeFound := s.findNode(l, key, &preds, &succs)
 		if nodeFound != nil { // indicating the key is already in the skip-list
 			if !nodeFound.flags.Get(marked) {
 				// We don't need to care about whether or not the node is fully linked,
 				// just replace the value.
-				nodeFound.storeVal(value)
-				return
+				if s.storeVal(nodeFound, value) {
+					return
+				}
 			}
 			// If the node is marked, represents some other goroutines is in the process of deleting this node,
 			// we need to add this node in next loop.
@@ -157,140 +961,45 @@ func (s *Int64Map[valueT]) Store(key int64, value valueT) {
 			continue
 		}
 
-		nn := newInt64Node(key, value, level)
+		nn := s.newNode(key, value, level)
 		for layer := 0; layer < level; layer++ {
 			nn.storeNext(layer, succs[layer])
 			preds[layer].atomicStoreNext(layer, nn)
 		}
 		nn.flags.SetTrue(fullyLinked)
 		unlockint64(preds, highestLocked)
-		atomic.AddInt64(&s.length, 1)
-		return
-	}
-}
-
-// randomlevel returns a random level and update the highest level if needed.
-func (s *Int64Map[valueT]) randomlevel() int {
-	// Generate random level.
-	level := randomLevel()
-	// Update highest level if possible.
-	for {
-		hl := atomic.LoadUint64(&s.highestLevel)
-		if uint64(level) <= hl {
-			break
-		}
-		if atomic.CompareAndSwapUint64(&s.highestLevel, hl, uint64(level)) {
-			break
-		}
-	}
-	return level
-}
-
-// Load returns the value stored in the map for a key, or nil if no
-// value is present.
-// The ok result indicates whether value was found in the map.
-func (s *Int64Map[valueT]) Load(key int64) (value valueT, ok bool) {
-	x := s.header
-	for i := int(atomic.LoadUint64(&s.highestLevel)) - 1; i >= 0; i-- {
-		nex := x.atomicLoadNext(i)
-		for nex != nil && (nex.key < key) {
-			x = nex
-			nex = x.atomicLoadNext(i)
-		}
-
-		// Check if the key already in the skip list.
-		if nex != nil && nex.key == key {
-			if nex.flags.MGet(fullyLinked|marked, fullyLinked) {
-				return nex.loadVal(), true
-			}
-			return
-		}
-	}
-	return
-}
-
-// LoadAndDelete deletes the value for a key, returning the previous value if any.
-// The loaded result reports whether the key was present.
-// (Modified from Delete)
-func (s *Int64Map[valueT]) LoadAndDelete(key int64) (value valueT, loaded bool) {
-	var (
-		nodeToDelete *int64node[valueT]
-		isMarked     bool // represents if this operation mark the node
-		topLayer     = -1
-		preds, succs [maxLevel]*int64node[valueT]
-	)
-	for {
-		lFound := s.findNodeDelete(key, &preds, &succs)
-		if isMarked || // this process mark this node or we can find this node in the skip list
-			lFound != -1 && succs[lFound].flags.MGet(fullyLinked|marked, fullyLinked) && (int(succs[lFound].level)-1) == lFound {
-			if !isMarked { // we don't mark this node for now
-				nodeToDelete = succs[lFound]
-				topLayer = lFound
-				nodeToDelete.mu.Lock()
-				if nodeToDelete.flags.Get(marked) {
-					// The node is marked by another process,
-					// the physical deletion will be accomplished by another process.
-					nodeToDelete.mu.Unlock()
-					return
-				}
-				nodeToDelete.flags.SetTrue(marked)
-				isMarked = true
-			}
-			// Accomplish the physical deletion.
-			var (
-				highestLocked        = -1 // the highest level being locked by this process
-				valid                = true
-				pred, succ, prevPred *int64node[valueT]
-			)
-			for layer := 0; valid && (layer <= topLayer); layer++ {
-				pred, succ = preds[layer], succs[layer]
-				if pred != prevPred { // the node in this layer could be locked by previous loop
-					pred.mu.Lock()
-					highestLocked = layer
-					prevPred = pred
-				}
-				// valid check if there is another node has inserted into the skip list in this layer
-				// during this process, or the previous is deleted by another process.
-				// It is valid if:
-				// 1. the previous node exists.
-				// 2. no another node has inserted into the skip list in this layer.
-				valid = !pred.flags.Get(marked) && pred.loadNext(layer) == succ
-			}
-			if !valid {
-				unlockint64(preds, highestLocked)
-				continue
-			}
-			for i := topLayer; i >= 0; i-- {
-				// Now we own the `nodeToDelete`, no other goroutine will modify it.
-				// So we don't need `nodeToDelete.loadNext`
-				preds[i].atomicStoreNext(i, nodeToDelete.loadNext(i))
-			}
-			nodeToDelete.mu.Unlock()
-			unlockint64(preds, highestLocked)
-			atomic.AddInt64(&s.length, -1)
-			return nodeToDelete.loadVal(), true
+		if s.index != nil {
+			s.indexInsert(l, nn)
 		}
+		atomic.AddInt64(&l.length, 1)
 		return
 	}
 }
 
-// LoadOrStore returns the existing value for the key if present.
-// Otherwise, it stores and returns the given value.
-// The loaded result is true if the value was loaded, false if stored.
+// Swap swaps the value for a key and returns the previous value if any.
+// The loaded result reports whether the key was present.
 // (Modified from Store)
-func (s *Int64Map[valueT]) LoadOrStore(key int64, value valueT) (actual valueT, loaded bool) {
-	var (
-		level        int
-		preds, succs [maxLevel]*int64node[valueT]
-		hl           = int(atomic.LoadUint64(&s.highestLevel))
-	)
+func (s *Int64Map[valueT]) Swap(key int64, value valueT) (previous valueT, loaded bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	level := s.randomlevel(l)
+	var preds, succs [maxLevel]*int64node[valueT]
 	for {
-		nodeFound := s.findNode(key, &preds, &succs)
+		nodeFound := s.findNode(l, key, &preds, &succs)
 		if nodeFound != nil { // indicating the key is already in the skip-list
 			if !nodeFound.flags.Get(marked) {
 				// We don't need to care about whether or not the node is fully linked,
-				// just return the value.
-				return nodeFound.loadVal(), true
+				// just replace the value.
+				if previous, ok := s.swapVal(nodeFound, value); ok {
+					return previous, true
+				}
 			}
 			// If the node is marked, represents some other goroutines is in the process of deleting this node,
 			// we need to add this node in next loop.
@@ -303,16 +1012,6 @@ func (s *Int64Map[valueT]) LoadOrStore(key int64, value valueT) (actual valueT,
 			valid                = true
 			pred, succ, prevPred *int64node[valueT]
 		)
-		if level == 0 {
-			level = s.randomlevel()
-			if level > hl {
-				// If the highest level is updated, usually means that many goroutines
-				// are inserting items. Hopefully we can find a better path in next loop.
-				// TODO(zyh): consider filling the preds if s.header[level].next == nil,
-				// but this strategy's performance is almost the same as the existing method.
-				continue
-			}
-		}
 		for layer := 0; valid && layer < level; layer++ {
 			pred = preds[layer]   // target node's previous node
 			succ = succs[layer]   // target node's next node
@@ -332,57 +1031,190 @@ func (s *Int64Map[valueT]) LoadOrStore(key int64, value valueT) (actual valueT,
 			continue
 		}
 
-		nn := newInt64Node(key, value, level)
+		nn := s.newNode(key, value, level)
 		for layer := 0; layer < level; layer++ {
 			nn.storeNext(layer, succs[layer])
 			preds[layer].atomicStoreNext(layer, nn)
 		}
 		nn.flags.SetTrue(fullyLinked)
 		unlockint64(preds, highestLocked)
-		atomic.AddInt64(&s.length, 1)
-		return value, false
+		if s.index != nil {
+			s.indexInsert(l, nn)
+		}
+		atomic.AddInt64(&l.length, 1)
+		return previous, false
 	}
 }
 
-// LoadOrStoreLazy returns the existing value for the key if present.
-// Otherwise, it stores and returns the given value from f, f will only be called once.
-// The loaded result is true if the value was loaded, false if stored.
-// (Modified from LoadOrStore)
-func (s *Int64Map[valueT]) LoadOrStoreLazy(key int64, f func() valueT) (actual valueT, loaded bool) {
-	var (
-		level        int
-		preds, succs [maxLevel]*int64node[valueT]
-		hl           = int(atomic.LoadUint64(&s.highestLevel))
-	)
+// CompareAndSwap swaps the old and new values for key if the value stored in the map is equal to old.
+// The values are compared as interfaces, so it panics if the value type is not comparable,
+// like sync.Map. Use CompareAndSwapFunc for other value types.
+func (s *Int64Map[valueT]) CompareAndSwap(key int64, old, new valueT) (swapped bool) {
+	return s.CompareAndSwapFunc(key, old, new, equalValue[valueT])
+}
+
+// CompareAndSwapFunc swaps the old and new values for key if equal(value, old) returns true,
+// where value is the value stored in the map. It returns false if the key is not present.
+//
+// CompareAndSwapFunc is lock-free, the value is replaced by an atomic compare-and-swap, and equal
+// may be called more than once if the value is changed concurrently. Like the racing Store and
+// Delete, a concurrent Store may overwrite the new value without being noticed.
+func (s *Int64Map[valueT]) CompareAndSwapFunc(key int64, old, new valueT, equal func(a, b valueT) bool) (swapped bool) {
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	x := s.ceilingNode(l, key)
+	if x == nil || !(x.key == key) {
+		return false
+	}
+	for {
+		// Load the value before checking the flags, see markIfSame and commit.
+		p := atomic.LoadPointer(&x.value)
+		if x.flags.Get(pending) {
+			x.flags.Wait(pending)
+			continue
+		}
+		if x.flags.Get(marked) || !equal(*(*valueT)(p), old) {
+			return false
+		}
+		if atomic.CompareAndSwapPointer(&x.value, p, s.newVal(new, p)) {
+			return true
+		}
+	}
+}
+
+// CompareAndDelete deletes the entry for key if its value is equal to old.
+// The values are compared as interfaces, so it panics if the value type is not comparable,
+// like sync.Map. Use CompareAndDeleteFunc for other value types.
+func (s *Int64Map[valueT]) CompareAndDelete(key int64, old valueT) (deleted bool) {
+	return s.CompareAndDeleteFunc(key, old, equalValue[valueT])
+}
+
+// CompareAndDeleteFunc deletes the entry for key if equal(value, old) returns true,
+// where value is the value stored in the map. It returns false if the key is not present.
+// (Modified from LoadAndDelete)
+func (s *Int64Map[valueT]) CompareAndDeleteFunc(key int64, old valueT, equal func(a, b valueT) bool) (deleted bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var preds, succs [maxLevel]*int64node[valueT]
+	for {
+		nodeToDelete := s.ceilingNode(l, key)
+		if nodeToDelete == nil || !(nodeToDelete.key == key) {
+			return false
+		}
+		nodeToDelete.mu.Lock()
+		if nodeToDelete.flags.Get(marked) {
+			// The node is marked by another process,
+			// the physical deletion will be accomplished by another process.
+			nodeToDelete.mu.Unlock()
+			return false
+		}
+		p := atomic.LoadPointer(&nodeToDelete.value)
+		var equaled bool
+		callLocked(&nodeToDelete.mu, func() { equaled = equal(*(*valueT)(p), old) })
+		if !equaled {
+			nodeToDelete.mu.Unlock()
+			return false
+		}
+		if !s.markIfSame(nodeToDelete, p) {
+			// A lock-free writer has replaced the value after it is loaded, compare it again.
+			nodeToDelete.mu.Unlock()
+			continue
+		}
+		atomic.AddInt64(&l.length, -1)
+		s.unlinkNode(l, nodeToDelete, &preds, &succs)
+		return true
+	}
+}
+
+// Compute atomically computes the value for a key, f is called with the current value and
+// whether the key is present, and returns the new value with the operation to apply:
+// OpKeep leaves the map unchanged, OpStore stores the new value (inserting the key if absent),
+// OpDelete deletes the key if present. Compute returns the value of the key after the operation
+// and whether the key is present.
+//
+// The computation is atomic against all the concurrent writers of the same key, f is called
+// without holding any locks if the key is absent, and under the node's lock otherwise.
+// f may be called more than once if the key is changed concurrently, and it must not
+// call the methods that modify the skipmap. If f panics, the lock is released and the
+// skipmap is left unchanged before the panic propagates.
+// (Modified from Store)
+func (s *Int64Map[valueT]) Compute(key int64, f func(old valueT, loaded bool) (new valueT, op Op)) (actual valueT, ok bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	level := s.randomlevel(l)
+	var preds, succs [maxLevel]*int64node[valueT]
 	for {
-		nodeFound := s.findNode(key, &preds, &succs)
+		nodeFound := s.findNode(l, key, &preds, &succs)
 		if nodeFound != nil { // indicating the key is already in the skip-list
-			if !nodeFound.flags.Get(marked) {
-				// We don't need to care about whether or not the node is fully linked,
-				// just return the value.
-				return nodeFound.loadVal(), true
+			// Wait for the node to be fully linked or removed, only the fully linked node can be deleted.
+			if !s.valid(nodeFound) {
+				continue
+			}
+			nodeFound.mu.Lock()
+			if nodeFound.flags.Get(marked) {
+				nodeFound.mu.Unlock()
+				continue
+			}
+			p := atomic.LoadPointer(&nodeFound.value)
+			old := *(*valueT)(p)
+			var (
+				newValue valueT
+				op       Op
+			)
+			// The node is not marked yet, so the skipmap is still valid if f panics.
+			callLocked(&nodeFound.mu, func() { newValue, op = f(old, true) })
+			switch op {
+			case OpStore:
+				// The lock-free writers (e.g. Store) may have replaced the value, compute it again.
+				if !atomic.CompareAndSwapPointer(&nodeFound.value, p, s.newVal(newValue, p)) {
+					nodeFound.mu.Unlock()
+					continue
+				}
+				nodeFound.mu.Unlock()
+				return newValue, true
+			case OpDelete:
+				// Like OpStore, compute it again if the value has been replaced.
+				if !s.markIfSame(nodeFound, p) {
+					nodeFound.mu.Unlock()
+					continue
+				}
+				atomic.AddInt64(&l.length, -1)
+				preds = [maxLevel]*int64node[valueT]{}
+				s.unlinkNode(l, nodeFound, &preds, &succs)
+				return actual, false
+			default:
+				nodeFound.mu.Unlock()
+				return old, true
 			}
-			// If the node is marked, represents some other goroutines is in the process of deleting this node,
-			// we need to add this node in next loop.
-			continue
 		}
 
+		newValue, op := f(actual, false)
+		if op != OpStore {
+			return actual, false
+		}
 		// Add this node into skip list.
 		var (
 			highestLocked        = -1 // the highest level being locked by this process
 			valid                = true
 			pred, succ, prevPred *int64node[valueT]
 		)
-		if level == 0 {
-			level = s.randomlevel()
-			if level > hl {
-				// If the highest level is updated, usually means that many goroutines
-				// are inserting items. Hopefully we can find a better path in next loop.
-				// TODO(zyh): consider filling the preds if s.header[level].next == nil,
-				// but this strategy's performance is almost the same as the existing method.
-				continue
-			}
-		}
 		for layer := 0; valid && layer < level; layer++ {
 			pred = preds[layer]   // target node's previous node
 			succ = succs[layer]   // target node's next node
@@ -395,49 +1227,529 @@ func (s *Int64Map[valueT]) LoadOrStoreLazy(key int64, f func() valueT) (actual v
 			// It is valid if:
 			// 1. The previous node and next node both are not marked.
 			// 2. The previous node's next node is succ in this layer.
-			valid = !pred.flags.Get(marked) && pred.loadNext(layer) == succ && (succ == nil || !succ.flags.Get(marked))
+			valid = !pred.flags.Get(marked) && (succ == nil || !succ.flags.Get(marked)) && pred.loadNext(layer) == succ
 		}
 		if !valid {
 			unlockint64(preds, highestLocked)
 			continue
 		}
-		value := f()
-		nn := newInt64Node(key, value, level)
+
+		nn := s.newNode(key, newValue, level)
 		for layer := 0; layer < level; layer++ {
 			nn.storeNext(layer, succs[layer])
 			preds[layer].atomicStoreNext(layer, nn)
 		}
 		nn.flags.SetTrue(fullyLinked)
 		unlockint64(preds, highestLocked)
-		atomic.AddInt64(&s.length, 1)
-		return value, false
+		if s.index != nil {
+			s.indexInsert(l, nn)
+		}
+		atomic.AddInt64(&l.length, 1)
+		return newValue, true
 	}
 }
 
-// Delete deletes the value for a key.
-func (s *Int64Map[valueT]) Delete(key int64) bool {
+// StoreBatch stores the values for the keys, values[i] is the value of keys[i]. It is the same as
+// calling Store for the keys in order, i.e. the last value wins if a key is duplicated, but the keys
+// are sorted first and stored by StoreSorted. It panics if keys and values have different lengths.
+func (s *Int64Map[valueT]) StoreBatch(keys []int64, values []valueT) {
+	if len(keys) != len(values) {
+		panic("skipmap: keys and values have different lengths")
+	}
+	idx := make([]int, len(keys))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(i, j int) bool {
+		return (keys[idx[i]] < keys[idx[j]])
+	})
+	sortedKeys := make([]int64, 0, len(keys))
+	sortedValues := make([]valueT, 0, len(values))
+	for n, i := range idx {
+		if n+1 < len(idx) && keys[idx[n+1]] == keys[i] {
+			continue // overwritten by the later one
+		}
+		sortedKeys = append(sortedKeys, keys[i])
+		sortedValues = append(sortedValues, values[i])
+	}
+	s.storeSorted(sortedKeys, sortedValues)
+}
+
+// StoreSorted stores the values for the keys, values[i] is the value of keys[i]. The keys must be
+// strictly ordered by the skipmap's order, or ErrUnsorted is returned and nothing is stored.
+// It panics if keys and values have different lengths.
+//
+// The search for each key resumes from the predecessors of the previous one, and the keys
+// in the same gap of the skipmap are inserted with the predecessors locked once,
+// so it is much faster than calling Store for the keys one by one.
+func (s *Int64Map[valueT]) StoreSorted(keys []int64, values []valueT) error {
+	if len(keys) != len(values) {
+		panic("skipmap: keys and values have different lengths")
+	}
+	if !s.isSorted(keys) {
+		return ErrUnsorted
+	}
+	s.storeSorted(keys, values)
+	return nil
+}
+
+// storeSorted stores the values for the sorted keys, see StoreSorted.
+func (s *Int64Map[valueT]) storeSorted(keys []int64, values []valueT) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var preds, succs [maxLevel]*int64node[valueT]
+	for i := 0; i < len(keys); {
+		i = s.storeRun(l, keys, values, i, &preds, &succs)
+	}
+}
+
+// storeRun stores keys[i] and the following keys before the successor of keys[i] at level 0,
+// and returns the index of the next key to store. The preds are used as the finger of the search
+// (see findNodeFrom), and they are locked once for the whole run: each new node is locked before
+// it is linked, and it replaces the predecessors below its level for the next key. The keys are
+// compared while holding the locks, so they are released by a deferred call if the comparison panics.
+// (Modified from Store)
+func (s *Int64Map[valueT]) storeRun(l *int64list[valueT], keys []int64, values []valueT, i int, preds, succs *[maxLevel]*int64node[valueT]) int {
+	key := keys[i]
+	s.findNodeFrom(l, key, preds, succs)
+	if nodeFound := succs[0]; nodeFound != nil && nodeFound.key == key {
+		if !nodeFound.flags.Get(marked) && s.storeVal(nodeFound, values[i]) {
+			return i + 1
+		}
+		// The node is being deleted, search from the header again after it is removed.
+		*preds = [maxLevel]*int64node[valueT]{}
+		return i
+	}
+
 	var (
-		nodeToDelete *int64node[valueT]
-		isMarked     bool // represents if this operation mark the node
-		topLayer     = -1
-		preds, succs [maxLevel]*int64node[valueT]
+		held     int // the predecessors below this level are locked by this process
+		inserted int
 	)
+	defer func() {
+		if held > 0 {
+			unlockint64(*preds, held-1)
+		}
+		atomic.AddInt64(&l.length, int64(inserted))
+	}()
 	for {
-		lFound := s.findNodeDelete(key, &preds, &succs)
-		if isMarked || // this process mark this node or we can find this node in the skip list
-			lFound != -1 && succs[lFound].flags.MGet(fullyLinked|marked, fullyLinked) && (int(succs[lFound].level)-1) == lFound {
-			if !isMarked { // we don't mark this node for now
-				nodeToDelete = succs[lFound]
-				topLayer = lFound
-				nodeToDelete.mu.Lock()
-				if nodeToDelete.flags.Get(marked) {
-					// The node is marked by another process,
-					// the physical deletion will be accomplished by another process.
-					nodeToDelete.mu.Unlock()
-					return false
+		level := s.randomlevel(l)
+		valid := true
+		for layer := 0; valid && layer < level; layer++ {
+			pred, succ := preds[layer], succs[layer]
+			if pred == nil {
+				// The highest level is raised after the search, search this level from the header.
+				pred, succ = l.header, l.header.atomicLoadNext(layer)
+				for succ != nil && (succ.key < keys[i]) {
+					pred = succ
+					succ = pred.atomicLoadNext(layer)
+				}
+				preds[layer], succs[layer] = pred, succ
+			}
+			if layer >= held {
+				if layer == 0 || pred != preds[layer-1] { // the node could be locked by previous loop
+					pred.mu.Lock()
+				}
+				held = layer + 1
+			}
+			valid = !pred.flags.Get(marked) && (succ == nil || !succ.flags.Get(marked)) && pred.loadNext(layer) == succ
+		}
+		if !valid {
+			unlockint64(*preds, held-1)
+			held = 0
+			// The finger may be stale, search from the header in next call.
+			*preds = [maxLevel]*int64node[valueT]{}
+			break
+		}
+
+		nn := s.newNode(keys[i], values[i], level)
+		// No one can see the new node now, so locking it never blocks.
+		nn.mu.Lock()
+		for layer := 0; layer < level; layer++ {
+			nn.storeNext(layer, succs[layer])
+			preds[layer].atomicStoreNext(layer, nn)
+		}
+		nn.flags.SetTrue(fullyLinked)
+		inserted++
+		if s.index != nil {
+			s.indexInsert(l, nn)
+		}
+		// Replace the predecessors below the level with the new node, and unlock the ones
+		// which are not the predecessors at any level.
+		var prevPred *int64node[valueT]
+		for layer := 0; layer < level; layer++ {
+			if pred := preds[layer]; pred != prevPred {
+				prevPred = pred
+				if level >= held || preds[level] != pred {
+					pred.mu.Unlock()
+				}
+			}
+			preds[layer] = nn
+		}
+
+		i++
+		if i == len(keys) || (succs[0] != nil && !(keys[i] < succs[0].key)) {
+			unlockint64(*preds, held-1)
+			held = 0
+			break
+		}
+	}
+	return i
+}
+
+// randomlevel returns a random level and update the highest level if needed.
+func (s *Int64Map[valueT]) randomlevel(l *int64list[valueT]) int {
+	// Generate random level.
+	level := randomLevel()
+	// Update highest level if possible.
+	for {
+		hl := atomic.LoadUint64(&l.highestLevel)
+		if uint64(level) <= hl {
+			break
+		}
+		if atomic.CompareAndSwapUint64(&l.highestLevel, hl, uint64(level)) {
+			break
+		}
+	}
+	return level
+}
+
+// Load returns the value stored in the map for a key, or nil if no
+// value is present.
+// The ok result indicates whether value was found in the map.
+func (s *Int64Map[valueT]) Load(key int64) (value valueT, ok bool) {
+	l := s.load()
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.atomicLoadNext(i)
+		for nex != nil && (nex.key < key) {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+
+		// Check if the key already in the skip list.
+		if nex != nil && nex.key == key {
+			if s.valid(nex) {
+				return nex.loadVal(), true
+			}
+			return
+		}
+	}
+	return
+}
+
+// LoadMany loads the values for the keys, out[i] and found[i] are the results of Load(keys[i]).
+// It panics if out or found is shorter than keys.
+//
+// The keys are looked up in the skipmap's order, and the search for each key resumes from the
+// predecessors of the previous one, so it is several times faster than calling Load for the keys
+// one by one if they are close to each other. The keys are sorted first (which allocates) unless
+// they are already in order. Like Load, LoadMany is wait-free unless the skipmap is created
+// with WithTxn.
+func (s *Int64Map[valueT]) LoadMany(keys []int64, out []valueT, found []bool) {
+	if len(out) < len(keys) || len(found) < len(keys) {
+		panic("skipmap: out or found is shorter than keys")
+	}
+	var idx []int
+	for i := 1; i < len(keys); i++ {
+		if keys[i] < keys[i-1] {
+			idx = make([]int, len(keys))
+			for i := range idx {
+				idx[i] = i
+			}
+			sort.Slice(idx, func(i, j int) bool {
+				return (keys[idx[i]] < keys[idx[j]])
+			})
+			break
+		}
+	}
+	var (
+		l     = s.load()
+		preds [maxLevel]*int64node[valueT]
+	)
+	for n := range keys {
+		i := n
+		if idx != nil {
+			i = idx[n]
+		}
+		key := keys[i]
+		if x := s.ceilingFrom(l, key, &preds); x != nil && x.key == key && s.valid(x) {
+			out[i], found[i] = x.loadVal(), true
+		} else {
+			var zero valueT
+			out[i], found[i] = zero, false
+		}
+	}
+}
+
+// ceilingFrom returns the first node whose key is greater than or equal to the given key at level 0.
+// Unlike ceilingNode, the search climbs from the preds only as high as needed and descends from there,
+// so it only costs O(log d) for the distance d between the keys. The preds must be empty or the
+// predecessors of a key less than or equal to the given key, and they are updated for the next search.
+func (s *Int64Map[valueT]) ceilingFrom(l *int64list[valueT], key int64, preds *[maxLevel]*int64node[valueT]) *int64node[valueT] {
+	var (
+		hl = int(atomic.LoadUint64(&l.highestLevel))
+		h  int
+	)
+	// Climb while the next node at this level is still less than the key.
+	for h < hl-1 && preds[h] != nil && preds[h+1] != nil {
+		nex := preds[h].atomicLoadNext(h)
+		if nex == nil || !(nex.key < key) {
+			break
+		}
+		h++
+	}
+	x := preds[h]
+	if x == nil {
+		x, h = l.header, hl-1
+	}
+	var nex *int64node[valueT]
+	for i := h; i >= 0; i-- {
+		if p := preds[i]; p != nil && p != l.header && (x == l.header || (x.key < p.key)) {
+			x = p
+		}
+		nex = x.atomicLoadNext(i)
+		for nex != nil && (nex.key < key) {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+		preds[i] = x
+	}
+	return nex
+}
+
+// nextValid returns the first node which is fully linked and unmarked at level 0,
+// starting from x (inclusive), or nil if there is no such node.
+func (s *Int64Map[valueT]) nextValid(x *int64node[valueT]) *int64node[valueT] {
+	for x != nil && !s.valid(x) {
+		x = x.atomicLoadNext(0)
+	}
+	return x
+}
+
+// seekLT returns the last node at level 0 whose key is less than the given key.
+// The returned node could be the header, and it may be marked or not fully linked.
+func (s *Int64Map[valueT]) seekLT(l *int64list[valueT], key int64) *int64node[valueT] {
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.atomicLoadNext(i)
+		for nex != nil && (nex.key < key) {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+	}
+	return x
+}
+
+// seekLE returns the last node at level 0 whose key is less than or equal to the given key.
+// The returned node could be the header, and it may be marked or not fully linked.
+func (s *Int64Map[valueT]) seekLE(l *int64list[valueT], key int64) *int64node[valueT] {
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.atomicLoadNext(i)
+		for nex != nil && !(key < nex.key) {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+	}
+	return x
+}
+
+// beforeLo reports whether the key is before the range starting from lo.
+func (s *Int64Map[valueT]) beforeLo(key, lo int64, bounds Bounds) bool {
+	if bounds&ExcludeLo != 0 {
+		return !(lo < key)
+	}
+	return (key < lo)
+}
+
+// afterHi reports whether the key is after the range ending at hi.
+func (s *Int64Map[valueT]) afterHi(key, hi int64, bounds Bounds) bool {
+	if bounds&ExcludeHi != 0 {
+		return !(key < hi)
+	}
+	return (hi < key)
+}
+
+// ceilingNode returns the first valid node whose key is greater than or equal to the given key.
+func (s *Int64Map[valueT]) ceilingNode(l *int64list[valueT], key int64) *int64node[valueT] {
+	return s.nextValid(s.seekLT(l, key).atomicLoadNext(0))
+}
+
+// higherNode returns the first valid node whose key is greater than the given key.
+func (s *Int64Map[valueT]) higherNode(l *int64list[valueT], key int64) *int64node[valueT] {
+	return s.nextValid(s.seekLE(l, key).atomicLoadNext(0))
+}
+
+// lowerNode returns the last valid node whose key is less than the given key.
+func (s *Int64Map[valueT]) lowerNode(l *int64list[valueT], key int64) *int64node[valueT] {
+	for {
+		x := s.seekLT(l, key)
+		if x == l.header {
+			return nil
+		}
+		if s.valid(x) {
+			return x
+		}
+		// The node is being inserted or deleted, there are no back pointers,
+		// so search again for the predecessor of this node.
+		key = x.key
+	}
+}
+
+// floorNode returns the last valid node whose key is less than or equal to the given key.
+func (s *Int64Map[valueT]) floorNode(l *int64list[valueT], key int64) *int64node[valueT] {
+	x := s.seekLE(l, key)
+	if x == l.header {
+		return nil
+	}
+	if s.valid(x) {
+		return x
+	}
+	return s.lowerNode(l, x.key)
+}
+
+// Floor returns the greatest key less than or equal to the given key and its value.
+// The keys are compared in the order used by Range, so it is reversed for the descending skipmap.
+// The ok result indicates whether such a key was found in the map.
+func (s *Int64Map[valueT]) Floor(key int64) (k int64, value valueT, ok bool) {
+	l := s.load()
+	if x := s.floorNode(l, key); x != nil {
+		return x.key, x.loadVal(), true
+	}
+	return
+}
+
+// Ceiling returns the least key greater than or equal to the given key and its value.
+// The keys are compared in the order used by Range, so it is reversed for the descending skipmap.
+// The ok result indicates whether such a key was found in the map.
+func (s *Int64Map[valueT]) Ceiling(key int64) (k int64, value valueT, ok bool) {
+	l := s.load()
+	if x := s.ceilingNode(l, key); x != nil {
+		return x.key, x.loadVal(), true
+	}
+	return
+}
+
+// Lower returns the greatest key strictly less than the given key and its value.
+// The keys are compared in the order used by Range, so it is reversed for the descending skipmap.
+// The ok result indicates whether such a key was found in the map.
+func (s *Int64Map[valueT]) Lower(key int64) (k int64, value valueT, ok bool) {
+	l := s.load()
+	if x := s.lowerNode(l, key); x != nil {
+		return x.key, x.loadVal(), true
+	}
+	return
+}
+
+// Higher returns the least key strictly greater than the given key and its value.
+// The keys are compared in the order used by Range, so it is reversed for the descending skipmap.
+// The ok result indicates whether such a key was found in the map.
+func (s *Int64Map[valueT]) Higher(key int64) (k int64, value valueT, ok bool) {
+	l := s.load()
+	if x := s.higherNode(l, key); x != nil {
+		return x.key, x.loadVal(), true
+	}
+	return
+}
+
+// firstNode returns the first valid node in the skipmap.
+func (s *Int64Map[valueT]) firstNode(l *int64list[valueT]) *int64node[valueT] {
+	return s.nextValid(l.header.atomicLoadNext(0))
+}
+
+// lastNode returns the last valid node in the skipmap.
+func (s *Int64Map[valueT]) lastNode(l *int64list[valueT]) *int64node[valueT] {
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.atomicLoadNext(i)
+		for nex != nil {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+	}
+	if x == l.header {
+		return nil
+	}
+	if s.valid(x) {
+		return x
+	}
+	return s.lowerNode(l, x.key)
+}
+
+// Min returns the first key in the skipmap and its value, i.e. the first one visited by Range.
+// The ok result indicates whether the map is not empty.
+func (s *Int64Map[valueT]) Min() (k int64, value valueT, ok bool) {
+	l := s.load()
+	if x := s.firstNode(l); x != nil {
+		return x.key, x.loadVal(), true
+	}
+	return
+}
+
+// Max returns the last key in the skipmap and its value, i.e. the last one visited by Range.
+// The ok result indicates whether the map is not empty.
+func (s *Int64Map[valueT]) Max() (k int64, value valueT, ok bool) {
+	l := s.load()
+	if x := s.lastNode(l); x != nil {
+		return x.key, x.loadVal(), true
+	}
+	return
+}
+
+// LoadAndDelete deletes the value for a key, returning the previous value if any.
+// The loaded result reports whether the key was present.
+// (Modified from Delete)
+func (s *Int64Map[valueT]) LoadAndDelete(key int64) (value valueT, loaded bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var (
+		nodeToDelete *int64node[valueT]
+		isMarked     bool // represents if this operation mark the node
+		unlinked     bool // the marked node has been unlinked
+		done         bool
+		topLayer     = -1
+		preds, succs [maxLevel]*int64node[valueT]
+	)
+	// The keys are compared while holding the lock of the marked node, see unlinkNode.
+	defer func() {
+		if isMarked && !done {
+			s.finishUnlink(l, nodeToDelete, unlinked)
+		}
+	}()
+	for {
+		lFound := s.findNodeDelete(l, key, &preds, &succs)
+		if isMarked || // this process mark this node or we can find this node in the skip list
+			lFound != -1 && s.valid(succs[lFound]) && (int(succs[lFound].level)-1) == lFound {
+			if !isMarked { // we don't mark this node for now
+				nodeToDelete = succs[lFound]
+				topLayer = lFound
+				nodeToDelete.mu.Lock()
+				if nodeToDelete.flags.Get(marked) {
+					// The node is marked by another process,
+					// the physical deletion will be accomplished by another process.
+					nodeToDelete.mu.Unlock()
+					return
 				}
 				nodeToDelete.flags.SetTrue(marked)
 				isMarked = true
+				atomic.AddInt64(&l.length, -1)
+				if s.snap != nil {
+					s.retire(l, nodeToDelete)
+				}
 			}
 			// Accomplish the physical deletion.
 			var (
@@ -457,7 +1769,7 @@ func (s *Int64Map[valueT]) Delete(key int64) bool {
 				// It is valid if:
 				// 1. the previous node exists.
 				// 2. no another node has inserted into the skip list in this layer.
-				valid = !pred.flags.Get(marked) && pred.atomicLoadNext(layer) == succ
+				valid = !pred.flags.Get(marked) && pred.loadNext(layer) == succ
 			}
 			if !valid {
 				unlockint64(preds, highestLocked)
@@ -470,35 +1782,1016 @@ func (s *Int64Map[valueT]) Delete(key int64) bool {
 			}
 			nodeToDelete.mu.Unlock()
 			unlockint64(preds, highestLocked)
-			atomic.AddInt64(&s.length, -1)
-			return true
+			unlinked = true
+			if s.index != nil {
+				s.indexDelete(l, nodeToDelete)
+			}
+			done = true
+			return nodeToDelete.loadVal(), true
 		}
-		return false
+		return
 	}
 }
 
-// Range calls f sequentially for each key and value present in the skipmap.
-// If f returns false, range stops the iteration.
-//
-// Range does not necessarily correspond to any consistent snapshot of the Map's
-// contents: no key will be visited more than once, but if the value for any key
-// is stored or deleted concurrently, Range may reflect any mapping for that key
-// from any point during the Range call.
-func (s *Int64Map[valueT]) Range(f func(key int64, value valueT) bool) {
-	x := s.header.atomicLoadNext(0)
-	for x != nil {
-		if !x.flags.MGet(fullyLinked|marked, fullyLinked) {
-			x = x.atomicLoadNext(0)
+// LoadOrStore returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value.
+// The loaded result is true if the value was loaded, false if stored.
+// (Modified from Store)
+func (s *Int64Map[valueT]) LoadOrStore(key int64, value valueT) (actual valueT, loaded bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var (
+		level        int
+		preds, succs [maxLevel]*int64node[valueT]
+		hl           = int(atomic.LoadUint64(&l.highestLevel))
+	)
+	for {
+		nodeFound := s.findNode(l, key, &preds, &succs)
+		if nodeFound != nil { // indicating the key is already in the skip-list
+			nodeFound.flags.Wait(pending)
+			if !nodeFound.flags.Get(marked) {
+				// We don't need to care about whether or not the node is fully linked,
+				// just return the value.
+				return nodeFound.loadVal(), true
+			}
+			// If the node is marked, represents some other goroutines is in the process of deleting this node,
+			// we need to add this node in next loop.
 			continue
 		}
-		if !f(x.key, x.loadVal()) {
-			break
+
+		// Add this node into skip list.
+		var (
+			highestLocked        = -1 // the highest level being locked by this process
+			valid                = true
+			pred, succ, prevPred *int64node[valueT]
+		)
+		if level == 0 {
+			level = s.randomlevel(l)
+			if level > hl {
+				// If the highest level is updated, usually means that many goroutines
+				// are inserting items. Hopefully we can find a better path in next loop.
+				// TODO(zyh): consider filling the preds if l.header[level].next == nil,
+				// but this strategy's performance is almost the same as the existing method.
+				continue
+			}
 		}
-		x = x.atomicLoadNext(0)
+		for layer := 0; valid && layer < level; layer++ {
+			pred = preds[layer]   // target node's previous node
+			succ = succs[layer]   // target node's next node
+			if pred != prevPred { // the node in this layer could be locked by previous loop
+				pred.mu.Lock()
+				highestLocked = layer
+				prevPred = pred
+			}
+			// valid check if there is another node has inserted into the skip list in this layer during this process.
+			// It is valid if:
+			// 1. The previous node and next node both are not marked.
+			// 2. The previous node's next node is succ in this layer.
+			valid = !pred.flags.Get(marked) && (succ == nil || !succ.flags.Get(marked)) && pred.loadNext(layer) == succ
+		}
+		if !valid {
+			unlockint64(preds, highestLocked)
+			continue
+		}
+
+		nn := s.newNode(key, value, level)
+		for layer := 0; layer < level; layer++ {
+			nn.storeNext(layer, succs[layer])
+			preds[layer].atomicStoreNext(layer, nn)
+		}
+		nn.flags.SetTrue(fullyLinked)
+		unlockint64(preds, highestLocked)
+		if s.index != nil {
+			s.indexInsert(l, nn)
+		}
+		atomic.AddInt64(&l.length, 1)
+		return value, false
+	}
+}
+
+// LoadOrStoreLazy returns the existing value for the key if present.
+// Otherwise, it stores and returns the given value from f, f will only be called once.
+// The loaded result is true if the value was loaded, false if stored.
+//
+// f is called without holding any locks, and the concurrent callers for the same key
+// wait for a single call of f, see LoadOrCompute.
+func (s *Int64Map[valueT]) LoadOrStoreLazy(key int64, f func() valueT) (actual valueT, loaded bool) {
+	actual, loaded, _ = s.LoadOrCompute(key, func() (valueT, error) {
+		return f(), nil
+	})
+	return actual, loaded
+}
+
+// LoadOrCompute returns the existing value for the key if present. Otherwise, it calls f and
+// stores the returned value if the error is nil. The loaded result is true if the value was loaded,
+// false if stored or f failed; the err result is the error returned by f.
+//
+// f is called without holding any locks, so it can be slow or even use the skipmap. The concurrent
+// callers for the same key wait for a single call of f, and share its result or error; f must not
+// call LoadOrCompute or LoadOrStoreLazy for the same key, which waits for itself. If f panics,
+// the panic propagates to its caller only, and one of the waiting callers calls f again.
+func (s *Int64Map[valueT]) LoadOrCompute(key int64, f func() (valueT, error)) (actual valueT, loaded bool, err error) {
+	for {
+		if v, ok := s.Load(key); ok {
+			return v, true, nil
+		}
+		c, started := s.joinCall(key)
+		if started {
+			s.doCall(c, f)
+			return c.value, c.loaded, c.err
+		}
+		c.wg.Wait()
+		if !c.panicked {
+			return c.value, c.err == nil, c.err
+		}
+		// f panicked in the caller of the call, try again.
+	}
+}
+
+// doCall calls f for the in-flight call c started by this caller, and finishes c,
+// the waiters of c are woken up even if f panics.
+func (s *Int64Map[valueT]) doCall(c *call[int64, valueT], f func() (valueT, error)) {
+	c.panicked = true
+	defer s.finishCall(c)
+	// The key may be stored by a call which has finished after the first Load,
+	// the key is stored before the call is finished, so f is not called again.
+	if v, ok := s.Load(c.key); ok {
+		c.value, c.loaded = v, true
+	} else if v, err := f(); err != nil {
+		c.err = err
+	} else {
+		c.value, c.loaded = s.LoadOrStore(c.key, v)
+	}
+	c.panicked = false
+}
+
+// joinCall returns the in-flight call for the key, or starts a new one if there is none.
+// The started result reports whether the call is started by this caller, who must finish it.
+func (s *Int64Map[valueT]) joinCall(key int64) (c *call[int64, valueT], started bool) {
+	return s.calls.join(key)
+}
+
+// finishCall removes the call started by joinCall and wakes up its waiters.
+func (s *Int64Map[valueT]) finishCall(c *call[int64, valueT]) {
+	s.calls.finish(c)
+}
+
+// Delete deletes the value for a key.
+func (s *Int64Map[valueT]) Delete(key int64) bool {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var (
+		nodeToDelete *int64node[valueT]
+		isMarked     bool // represents if this operation mark the node
+		unlinked     bool // the marked node has been unlinked
+		done         bool
+		topLayer     = -1
+		preds, succs [maxLevel]*int64node[valueT]
+	)
+	// The keys are compared while holding the lock of the marked node, see unlinkNode.
+	defer func() {
+		if isMarked && !done {
+			s.finishUnlink(l, nodeToDelete, unlinked)
+		}
+	}()
+	for {
+		lFound := s.findNodeDelete(l, key, &preds, &succs)
+		if isMarked || // this process mark this node or we can find this node in the skip list
+			lFound != -1 && s.valid(succs[lFound]) && (int(succs[lFound].level)-1) == lFound {
+			if !isMarked { // we don't mark this node for now
+				nodeToDelete = succs[lFound]
+				topLayer = lFound
+				nodeToDelete.mu.Lock()
+				if nodeToDelete.flags.Get(marked) {
+					// The node is marked by another process,
+					// the physical deletion will be accomplished by another process.
+					nodeToDelete.mu.Unlock()
+					return false
+				}
+				nodeToDelete.flags.SetTrue(marked)
+				isMarked = true
+				atomic.AddInt64(&l.length, -1)
+				if s.snap != nil {
+					s.retire(l, nodeToDelete)
+				}
+			}
+			// Accomplish the physical deletion.
+			var (
+				highestLocked        = -1 // the highest level being locked by this process
+				valid                = true
+				pred, succ, prevPred *int64node[valueT]
+			)
+			for layer := 0; valid && (layer <= topLayer); layer++ {
+				pred, succ = preds[layer], succs[layer]
+				if pred != prevPred { // the node in this layer could be locked by previous loop
+					pred.mu.Lock()
+					highestLocked = layer
+					prevPred = pred
+				}
+				// valid check if there is another node has inserted into the skip list in this layer
+				// during this process, or the previous is deleted by another process.
+				// It is valid if:
+				// 1. the previous node exists.
+				// 2. no another node has inserted into the skip list in this layer.
+				valid = !pred.flags.Get(marked) && pred.atomicLoadNext(layer) == succ
+			}
+			if !valid {
+				unlockint64(preds, highestLocked)
+				continue
+			}
+			for i := topLayer; i >= 0; i-- {
+				// Now we own the `nodeToDelete`, no other goroutine will modify it.
+				// So we don't need `nodeToDelete.loadNext`
+				preds[i].atomicStoreNext(i, nodeToDelete.loadNext(i))
+			}
+			nodeToDelete.mu.Unlock()
+			unlockint64(preds, highestLocked)
+			unlinked = true
+			if s.index != nil {
+				s.indexDelete(l, nodeToDelete)
+			}
+			done = true
+			return true
+		}
+		return false
+	}
+}
+
+// deleteNode marks the given node and removes it from the skipmap, the node must be fully linked.
+// It returns false if the node has been marked by another goroutine. The preds is used as a finger
+// (see findNodeFrom), it must be empty or the predecessors of a previous deleted node whose key is
+// less than the node's key.
+// (Modified from Delete)
+func (s *Int64Map[valueT]) deleteNode(l *int64list[valueT], nodeToDelete *int64node[valueT], preds, succs *[maxLevel]*int64node[valueT]) bool {
+	nodeToDelete.mu.Lock()
+	if nodeToDelete.flags.Get(marked) {
+		// The node is marked by another process,
+		// the physical deletion will be accomplished by another process.
+		nodeToDelete.mu.Unlock()
+		return false
+	}
+	nodeToDelete.flags.SetTrue(marked)
+	atomic.AddInt64(&l.length, -1)
+	s.unlinkNode(l, nodeToDelete, preds, succs)
+	return true
+}
+
+// unlinkNode retires and removes the given node from the skipmap, the caller must hold the node's lock
+// and have marked it. The lock is released after the node is removed. See deleteNode for the preds.
+// The keys are compared while holding the lock, so the removal is finished by a deferred call if the
+// comparison panics.
+func (s *Int64Map[valueT]) unlinkNode(l *int64list[valueT], nodeToDelete *int64node[valueT], preds, succs *[maxLevel]*int64node[valueT]) {
+	var unlinked, done bool
+	defer func() {
+		if !done {
+			s.finishUnlink(l, nodeToDelete, unlinked)
+		}
+	}()
+	if s.snap != nil {
+		s.retire(l, nodeToDelete)
+	}
+	topLayer := int(nodeToDelete.level) - 1
+	for {
+		s.findNodeFrom(l, nodeToDelete.key, preds, succs)
+		// Accomplish the physical deletion.
+		var (
+			highestLocked  = -1 // the highest level being locked by this process
+			valid          = true
+			pred, prevPred *int64node[valueT]
+		)
+		for layer := 0; valid && (layer <= topLayer); layer++ {
+			pred = preds[layer]
+			if pred != prevPred { // the node in this layer could be locked by previous loop
+				pred.mu.Lock()
+				highestLocked = layer
+				prevPred = pred
+			}
+			// It is valid if the previous node exists and still points to the node to delete.
+			valid = !pred.flags.Get(marked) && pred.loadNext(layer) == nodeToDelete
+		}
+		if !valid {
+			unlockint64(*preds, highestLocked)
+			// The finger may be stale, search from the header in next loop.
+			*preds = [maxLevel]*int64node[valueT]{}
+			continue
+		}
+		for i := topLayer; i >= 0; i-- {
+			// Now we own the `nodeToDelete`, no other goroutine will modify it.
+			// So we don't need `nodeToDelete.loadNext`
+			preds[i].atomicStoreNext(i, nodeToDelete.loadNext(i))
+		}
+		nodeToDelete.mu.Unlock()
+		unlockint64(*preds, highestLocked)
+		unlinked = true
+		if s.index != nil {
+			s.indexDelete(l, nodeToDelete)
+		}
+		done = true
+		return
+	}
+}
+
+// finishUnlink finishes the removal of the marked node n after a comparison panics in the middle of it.
+// If n is not unlinked yet, the caller must hold its lock, and it is removed without comparing the keys.
+// The span counts are rebuilt, since indexDelete compares the keys too.
+func (s *Int64Map[valueT]) finishUnlink(l *int64list[valueT], n *int64node[valueT], unlinked bool) {
+	if !unlinked {
+		unlinkMarkedint64(n, l.header)
+	}
+	if s.index != nil {
+		s.rebuildIndex(l)
+	}
+}
+
+// unlinkMarkedint64 removes the marked node n like unlinkNode, but the predecessors are found by
+// following the next pointers from the header until n instead of comparing the keys, which costs O(n).
+// The caller must hold the lock of n, it is released after n is removed.
+func unlinkMarkedint64[valueT any](n, header *int64node[valueT]) {
+	topLayer := int(n.level) - 1
+	var preds [maxLevel]*int64node[valueT]
+	for {
+		x, found := header, true
+		for layer := topLayer; found && layer >= 0; layer-- {
+			// The predecessor in the upper layer precedes n in this layer too.
+			nex := x.atomicLoadNext(layer)
+			for nex != nil && nex != n {
+				x = nex
+				nex = x.atomicLoadNext(layer)
+			}
+			preds[layer], found = x, nex == n
+		}
+		if !found {
+			continue // the search has passed a removed node, search from the header again
+		}
+		var (
+			highestLocked  = -1 // the highest level being locked by this process
+			valid          = true
+			pred, prevPred *int64node[valueT]
+		)
+		for layer := 0; valid && (layer <= topLayer); layer++ {
+			pred = preds[layer]
+			if pred != prevPred { // the node in this layer could be locked by previous loop
+				pred.mu.Lock()
+				highestLocked = layer
+				prevPred = pred
+			}
+			valid = !pred.flags.Get(marked) && pred.loadNext(layer) == n
+		}
+		if !valid {
+			unlockint64(preds, highestLocked)
+			continue
+		}
+		for i := topLayer; i >= 0; i-- {
+			preds[i].atomicStoreNext(i, n.loadNext(i))
+		}
+		n.mu.Unlock()
+		unlockint64(preds, highestLocked)
+		return
+	}
+}
+
+// PopMin deletes the first key in the skipmap, i.e. the value returned by Min,
+// and returns the key with its value. Concurrent calls never return the same key.
+// The ok result indicates whether the map is not empty.
+func (s *Int64Map[valueT]) PopMin() (k int64, value valueT, ok bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var preds, succs [maxLevel]*int64node[valueT]
+	for {
+		x := s.firstNode(l)
+		if x == nil {
+			return
+		}
+		if s.deleteNode(l, x, &preds, &succs) {
+			return x.key, x.loadVal(), true
+		}
+	}
+}
+
+// PopMax deletes the last key in the skipmap, i.e. the value returned by Max,
+// and returns the key with its value. Concurrent calls never return the same key.
+// The ok result indicates whether the map is not empty.
+func (s *Int64Map[valueT]) PopMax() (k int64, value valueT, ok bool) {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var preds, succs [maxLevel]*int64node[valueT]
+	for {
+		x := s.lastNode(l)
+		if x == nil {
+			return
+		}
+		if s.deleteNode(l, x, &preds, &succs) {
+			return x.key, x.loadVal(), true
+		}
+	}
+}
+
+// DeleteRange deletes the keys between lo and hi, the bounds reports which endpoints are excluded.
+// It returns the number of deleted keys.
+//
+// The keys are compared in the order used by Range, so lo is the endpoint visited first.
+// DeleteRange walks the range once and deletes the keys one by one, it is not atomic:
+// the keys stored or deleted concurrently within the range may or may not be deleted by it.
+func (s *Int64Map[valueT]) DeleteRange(lo, hi int64, bounds Bounds) int {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var (
+		x            *int64node[valueT]
+		preds, succs [maxLevel]*int64node[valueT]
+		deleted      int
+	)
+	if bounds&ExcludeLo != 0 {
+		x = s.higherNode(l, lo)
+	} else {
+		x = s.ceilingNode(l, lo)
+	}
+	for x != nil {
+		if s.afterHi(x.key, hi, bounds) {
+			break
+		}
+		if s.valid(x) && s.deleteNode(l, x, &preds, &succs) {
+			deleted++
+		}
+		x = x.atomicLoadNext(0)
+	}
+	return deleted
+}
+
+// DeleteBatch deletes the keys and returns the number of keys deleted. The keys are sorted first,
+// so the search for each key resumes from the predecessors of the previous one.
+func (s *Int64Map[valueT]) DeleteBatch(keys []int64) int {
+	sorted := make([]int64, len(keys))
+	copy(sorted, keys)
+	sort.Slice(sorted, func(i, j int) bool {
+		return (sorted[i] < sorted[j])
+	})
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
+	}
+	l := s.load()
+	var (
+		preds, succs [maxLevel]*int64node[valueT]
+		deleted      int
+	)
+	for _, key := range sorted {
+		for {
+			s.findNodeFrom(l, key, &preds, &succs)
+			if !preds[0].flags.Get(marked) {
+				break
+			}
+			// The search may pass a node inserted after the finger is deleted, search from the header again.
+			preds = [maxLevel]*int64node[valueT]{}
+		}
+		x := succs[0]
+		if x != nil && x.key == key && s.valid(x) && s.deleteNode(l, x, &preds, &succs) {
+			deleted++
+		}
+	}
+	return deleted
+}
+
+// indexInsert updates the span counts after nn is linked into the skipmap,
+// the caller must hold the index lock.
+func (s *Int64Map[valueT]) indexInsert(l *int64list[valueT], nn *int64node[valueT]) {
+	var (
+		preds [maxLevel]*int64node[valueT]
+		rank  [maxLevel]int // rank[i] is the number of nodes before preds[i], including itself
+		x     = l.header
+		r     int
+		hl    = int(atomic.LoadUint64(&l.highestLevel))
+	)
+	for i := hl - 1; i >= 0; i-- {
+		nex := x.loadNext(i)
+		for nex != nil && (nex.key < nn.key) {
+			r += x.spans()[i]
+			x = nex
+			nex = x.loadNext(i)
+		}
+		preds[i], rank[i] = x, r
+	}
+	// Now the rank of nn is rank[0]+1.
+	for i := 0; i < hl; i++ {
+		span := preds[i].spans()
+		if i < int(nn.level) {
+			if nn.loadNext(i) != nil {
+				nn.spans()[i] = span[i] - (rank[0] - rank[i])
+			}
+			span[i] = rank[0] - rank[i] + 1
+		} else if preds[i].loadNext(i) != nil {
+			span[i]++
+		}
+	}
+}
+
+// rebuildIndex recomputes the span counts of all the nodes in one pass over the bottom level,
+// see finishUnlink. The caller must hold the index lock.
+func (s *Int64Map[valueT]) rebuildIndex(l *int64list[valueT]) {
+	var (
+		tails [maxLevel]*int64node[valueT] // tails[i] is the last node visited at level i
+		ranks [maxLevel]int                // ranks[i] is the rank of tails[i], the header is 0
+		r     int
+	)
+	for i := range tails {
+		tails[i] = l.header
+	}
+	for x := l.header.loadNext(0); x != nil; x = x.loadNext(0) {
+		r++
+		for i := 0; i < int(x.level); i++ {
+			tails[i].spans()[i] = r - ranks[i]
+			tails[i], ranks[i] = x, r
+		}
+	}
+}
+
+// indexDelete updates the span counts after x is unlinked from the skipmap,
+// the caller must hold the index lock.
+func (s *Int64Map[valueT]) indexDelete(l *int64list[valueT], x *int64node[valueT]) {
+	pred := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := pred.loadNext(i)
+		for nex != nil && (nex.key < x.key) {
+			pred = nex
+			nex = pred.loadNext(i)
+		}
+		if nex == nil {
+			continue
+		}
+		if i < int(x.level) {
+			// The next node of pred was x at this level.
+			pred.spans()[i] += x.spans()[i] - 1
+		} else {
+			pred.spans()[i]--
+		}
+	}
+}
+
+// Rank returns the number of keys before the given key in the skipmap, i.e. the index
+// of the key in the order of Range. The ok result indicates whether the key was found,
+// if not, the rank is the index where the key would be inserted.
+//
+// Rank costs O(log n) if the skipmap is created with WithIndex, otherwise it walks
+// the keys from the first one, which costs O(n).
+func (s *Int64Map[valueT]) Rank(key int64) (rank int, ok bool) {
+	l := s.load()
+	if s.index == nil {
+		for x := s.firstNode(l); x != nil; x = s.nextValid(x.atomicLoadNext(0)) {
+			if !(x.key < key) {
+				return rank, x.key == key
+			}
+			rank++
+		}
+		return rank, false
+	}
+	s.index.RLock()
+	defer s.index.RUnlock()
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.loadNext(i)
+		for nex != nil && (nex.key < key) {
+			rank += x.spans()[i]
+			x = nex
+			nex = x.loadNext(i)
+		}
+	}
+	nex := x.loadNext(0)
+	return rank, nex != nil && nex.key == key
+}
+
+// At returns the key and value at the given index in the order of Range, i.e. the key
+// whose Rank is i. The ok result indicates whether the index is in range.
+//
+// At costs O(log n) if the skipmap is created with WithIndex, otherwise it walks
+// the keys from the first one, which costs O(n).
+func (s *Int64Map[valueT]) At(i int) (k int64, value valueT, ok bool) {
+	l := s.load()
+	if i < 0 {
+		return
+	}
+	if s.index == nil {
+		for x := s.firstNode(l); x != nil; x = s.nextValid(x.atomicLoadNext(0)) {
+			if i == 0 {
+				return x.key, x.loadVal(), true
+			}
+			i--
+		}
+		return
+	}
+	s.index.RLock()
+	defer s.index.RUnlock()
+	var (
+		x = l.header
+		r int // the rank of x, the header is 0
+	)
+	for level := int(atomic.LoadUint64(&l.highestLevel)) - 1; level >= 0; level-- {
+		nex := x.loadNext(level)
+		for nex != nil && r+x.spans()[level] <= i+1 {
+			r += x.spans()[level]
+			x = nex
+			nex = x.loadNext(level)
+		}
+		if r == i+1 {
+			return x.key, x.loadVal(), true
+		}
+	}
+	return
+}
+
+// countBefore returns the number of keys less than the given key, or less than or equal
+// to it if inclusive is true. The skipmap must be indexed and the caller must hold the index lock.
+func (s *Int64Map[valueT]) countBefore(l *int64list[valueT], key int64, inclusive bool) (n int) {
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.loadNext(i)
+		for nex != nil && ((nex.key < key) || inclusive && !(key < nex.key)) {
+			n += x.spans()[i]
+			x = nex
+			nex = x.loadNext(i)
+		}
+	}
+	return n
+}
+
+// CountRange returns the number of keys between lo and hi, the bounds reports which endpoints
+// are excluded. The exact result reports whether the number is exact or estimated.
+//
+// If the skipmap is created with WithIndex, the number is always exact and costs O(log n).
+// Otherwise, if there are thousands of keys in the range, the number is estimated from the nodes
+// in a higher level of the skip list in O(log n), the error is usually a few percent and within 20%;
+// if not, the keys at level 0 are counted.
+//
+// The keys are compared in the order used by Range, so lo is the endpoint visited first.
+// CountRange does not allocate nor call any callbacks.
+func (s *Int64Map[valueT]) CountRange(lo, hi int64, bounds Bounds) (n int, exact bool) {
+	l := s.load()
+	if s.index != nil {
+		s.index.RLock()
+		n = s.countBefore(l, hi, bounds&ExcludeHi == 0) - s.countBefore(l, lo, bounds&ExcludeLo != 0)
+		s.index.RUnlock()
+		if n < 0 {
+			n = 0
+		}
+		return n, true
+	}
+	x := l.header
+	for i := int(atomic.LoadUint64(&l.highestLevel)) - 1; i >= 0; i-- {
+		nex := x.atomicLoadNext(i)
+		for nex != nil && s.beforeLo(nex.key, lo, bounds) {
+			x = nex
+			nex = x.atomicLoadNext(i)
+		}
+		// Count the nodes in the range at this level. If the upper level already has
+		// enough nodes, this level is used for the estimation, which is more accurate.
+		estimate := n >= estimateThreshold
+		n = 0
+		for y := nex; y != nil && !s.afterHi(y.key, hi, bounds); y = y.atomicLoadNext(i) {
+			if i > 0 || s.valid(y) {
+				n++
+			}
+		}
+		if i > 0 && estimate {
+			// Every node in level i is expected to represent (1/p)^i nodes at level 0.
+			for j := 0; j < i; j++ {
+				n *= int(1 / p)
+			}
+			return n, false
+		}
+	}
+	return n, true
+}
+
+// collectPage collects at most limit entries starting from the node x, in the order used by Range,
+// or in the reverse order if reverse is true. The cursor is returned as next if there are no entries.
+func (s *Int64Map[valueT]) collectPage(l *int64list[valueT], x *int64node[valueT], cursor int64, limit int, reverse bool) (entries []Entry[int64, valueT], next int64, more bool) {
+	next = cursor
+	if limit > 0 && x != nil {
+		size := limit
+		if n := int(atomic.LoadInt64(&l.length)); n < size {
+			size = n
+		}
+		entries = make([]Entry[int64, valueT], 0, size)
+	}
+	for x != nil && len(entries) < limit {
+		entries = append(entries, Entry[int64, valueT]{Key: x.key, Value: x.loadVal()})
+		next = x.key
+		if reverse {
+			x = s.lowerNode(l, x.key)
+		} else {
+			x = s.nextValid(x.atomicLoadNext(0))
+		}
+	}
+	return entries, next, x != nil
+}
+
+// Page returns at most limit entries whose keys are after the cursor key, in the order used by Range.
+// The next result is the key of the last returned entry, which should be used as the cursor of
+// the next page; it is the cursor itself if no entries are returned. The more result reports
+// whether there are more keys after next.
+//
+// The cursor key does not need to be present in the skipmap, the page starts from its successor.
+// So the pagination works even if the cursor key has been deleted between calls.
+// Use FirstPage to get the first page.
+func (s *Int64Map[valueT]) Page(after int64, limit int) (entries []Entry[int64, valueT], next int64, more bool) {
+	l := s.load()
+	return s.collectPage(l, s.higherNode(l, after), after, limit, false)
+}
+
+// FirstPage returns at most limit entries from the first key in the skipmap, see Page.
+func (s *Int64Map[valueT]) FirstPage(limit int) (entries []Entry[int64, valueT], next int64, more bool) {
+	l := s.load()
+	var cursor int64
+	return s.collectPage(l, s.firstNode(l), cursor, limit, false)
+}
+
+// PageReverse returns at most limit entries whose keys are before the cursor key,
+// in the reverse order of Range. The results are the same as Page.
+//
+// Like RangeReverse, each entry costs O(log n) rather than O(1).
+// Use LastPage to get the first page in the reverse order.
+func (s *Int64Map[valueT]) PageReverse(before int64, limit int) (entries []Entry[int64, valueT], next int64, more bool) {
+	l := s.load()
+	return s.collectPage(l, s.lowerNode(l, before), before, limit, true)
+}
+
+// LastPage returns at most limit entries from the last key in the skipmap,
+// in the reverse order of Range, see PageReverse.
+func (s *Int64Map[valueT]) LastPage(limit int) (entries []Entry[int64, valueT], next int64, more bool) {
+	l := s.load()
+	var cursor int64
+	return s.collectPage(l, s.lastNode(l), cursor, limit, true)
+}
+
+// Range calls f sequentially for each key and value present in the skipmap.
+// If f returns false, range stops the iteration.
+//
+// Range does not necessarily correspond to any consistent snapshot of the Map's
+// contents: no key will be visited more than once, but if the value for any key
+// is stored or deleted concurrently, Range may reflect any mapping for that key
+// from any point during the Range call.
+func (s *Int64Map[valueT]) Range(f func(key int64, value valueT) bool) {
+	l := s.load()
+	x := l.header.atomicLoadNext(0)
+	for x != nil {
+		if !s.valid(x) {
+			x = x.atomicLoadNext(0)
+			continue
+		}
+		if !f(x.key, x.loadVal()) {
+			break
+		}
+		x = x.atomicLoadNext(0)
+	}
+}
+
+// RangeFrom calls f sequentially for each key and value present in the skipmap,
+// starting from the first key greater than or equal to start.
+// If f returns false, range stops the iteration.
+//
+// RangeFrom has the same consistency guarantees as Range.
+func (s *Int64Map[valueT]) RangeFrom(start int64, f func(key int64, value valueT) bool) {
+	l := s.load()
+	x := s.ceilingNode(l, start)
+	for x != nil {
+		if !s.valid(x) {
+			x = x.atomicLoadNext(0)
+			continue
+		}
+		if !f(x.key, x.loadVal()) {
+			break
+		}
+		x = x.atomicLoadNext(0)
+	}
+}
+
+// RangeBetween calls f sequentially for each key and value present in the skipmap
+// whose key is between lo and hi, the bounds reports which endpoints are excluded.
+// If f returns false, range stops the iteration.
+//
+// The keys are compared in the order used by Range, so lo is the endpoint visited first.
+// RangeBetween has the same consistency guarantees as Range.
+func (s *Int64Map[valueT]) RangeBetween(lo, hi int64, bounds Bounds, f func(key int64, value valueT) bool) {
+	l := s.load()
+	var x *int64node[valueT]
+	if bounds&ExcludeLo != 0 {
+		x = s.higherNode(l, lo)
+	} else {
+		x = s.ceilingNode(l, lo)
+	}
+	for x != nil {
+		if s.afterHi(x.key, hi, bounds) {
+			break
+		}
+		if !s.valid(x) {
+			x = x.atomicLoadNext(0)
+			continue
+		}
+		if !f(x.key, x.loadVal()) {
+			break
+		}
+		x = x.atomicLoadNext(0)
+	}
+}
+
+// RangeReverse calls f sequentially for each key and value present in the skipmap,
+// in the reverse order of Range. If f returns false, range stops the iteration.
+//
+// There are no back pointers in the skipmap, each step searches the predecessor
+// of the previous key, so it costs O(log n) per key rather than O(1) as Range.
+// RangeReverse has the same consistency guarantees as Range.
+func (s *Int64Map[valueT]) RangeReverse(f func(key int64, value valueT) bool) {
+	l := s.load()
+	x := s.lastNode(l)
+	for x != nil {
+		if !f(x.key, x.loadVal()) {
+			break
+		}
+		x = s.lowerNode(l, x.key)
+	}
+}
+
+// RangeReverseFrom calls f sequentially for each key and value present in the skipmap
+// in the reverse order of Range, starting from the last key less than or equal to start.
+// If f returns false, range stops the iteration.
+//
+// RangeReverseFrom has the same consistency guarantees and costs as RangeReverse.
+func (s *Int64Map[valueT]) RangeReverseFrom(start int64, f func(key int64, value valueT) bool) {
+	l := s.load()
+	x := s.floorNode(l, start)
+	for x != nil {
+		if !f(x.key, x.loadVal()) {
+			break
+		}
+		x = s.lowerNode(l, x.key)
+	}
+}
+
+// RangeReverseBetween calls f sequentially for each key and value present in the skipmap
+// whose key is between lo and hi in the reverse order of Range, the bounds reports which
+// endpoints are excluded. If f returns false, range stops the iteration.
+//
+// The keys are compared in the order used by Range, so hi is the endpoint visited first.
+// RangeReverseBetween has the same consistency guarantees and costs as RangeReverse.
+func (s *Int64Map[valueT]) RangeReverseBetween(lo, hi int64, bounds Bounds, f func(key int64, value valueT) bool) {
+	l := s.load()
+	var x *int64node[valueT]
+	if bounds&ExcludeHi != 0 {
+		x = s.lowerNode(l, hi)
+	} else {
+		x = s.floorNode(l, hi)
+	}
+	for x != nil {
+		if s.beforeLo(x.key, lo, bounds) {
+			break
+		}
+		if !f(x.key, x.loadVal()) {
+			break
+		}
+		x = s.lowerNode(l, x.key)
+	}
+}
+
+// Int64Iterator is a stateful iterator over a Int64Map, created by Iter.
+// It stays valid while other goroutines modify the skipmap and skips the keys being
+// inserted or deleted as Range does. It is not safe for concurrent use itself.
+type Int64Iterator[valueT any] struct {
+	s    *Int64Map[valueT]
+	l    *int64list[valueT] // the list of the current node
+	node *int64node[valueT]
+}
+
+// Iter returns an unpositioned iterator over the skipmap, call one of the
+// Seek methods to position it before use.
+//
+// SeekGE is the usual seek, i.e. Seek(key) of other iterators. It is not named Seek,
+// because Seek(int64) of Int64Map's iterator would look like io.Seeker but be a different method.
+func (s *Int64Map[valueT]) Iter() *Int64Iterator[valueT] {
+	return &Int64Iterator[valueT]{s: s}
+}
+
+// SeekGE moves the iterator to the first key greater than or equal to the given key,
+// and reports whether the iterator is valid.
+func (it *Int64Iterator[valueT]) SeekGE(key int64) bool {
+	it.l = it.s.load()
+	it.node = it.s.ceilingNode(it.l, key)
+	return it.node != nil
+}
+
+// SeekLE moves the iterator to the last key less than or equal to the given key,
+// and reports whether the iterator is valid.
+func (it *Int64Iterator[valueT]) SeekLE(key int64) bool {
+	it.l = it.s.load()
+	it.node = it.s.floorNode(it.l, key)
+	return it.node != nil
+}
+
+// SeekFirst moves the iterator to the first key, and reports whether the iterator is valid.
+func (it *Int64Iterator[valueT]) SeekFirst() bool {
+	it.l = it.s.load()
+	it.node = it.s.firstNode(it.l)
+	return it.node != nil
+}
+
+// SeekLast moves the iterator to the last key, and reports whether the iterator is valid.
+func (it *Int64Iterator[valueT]) SeekLast() bool {
+	it.l = it.s.load()
+	it.node = it.s.lastNode(it.l)
+	return it.node != nil
+}
+
+// Next moves the iterator to the next key, and reports whether the iterator is valid.
+// The iterator must be valid.
+func (it *Int64Iterator[valueT]) Next() bool {
+	if it.node.flags.Get(marked) {
+		// The current node has been deleted, the nodes inserted after it
+		// are only reachable from the skipmap.
+		it.node = it.s.higherNode(it.l, it.node.key)
+	} else {
+		it.node = it.s.nextValid(it.node.atomicLoadNext(0))
+	}
+	return it.node != nil
+}
+
+// Prev moves the iterator to the previous key, and reports whether the iterator is valid.
+// The iterator must be valid.
+func (it *Int64Iterator[valueT]) Prev() bool {
+	it.node = it.s.lowerNode(it.l, it.node.key)
+	return it.node != nil
+}
+
+// Valid reports whether the iterator is positioned at a key.
+func (it *Int64Iterator[valueT]) Valid() bool {
+	return it.node != nil
+}
+
+// Key returns the key at the current position. The iterator must be valid.
+func (it *Int64Iterator[valueT]) Key() int64 {
+	return it.node.key
+}
+
+// Value returns the latest value of the key at the current position. The iterator must be valid.
+func (it *Int64Iterator[valueT]) Value() valueT {
+	return it.node.loadVal()
+}
+
+// Clear deletes all the keys, resulting in an empty skipmap.
+//
+// Clear replaces the skip list with an empty one atomically, so the concurrent readers see either
+// the old contents or an empty skipmap, and a positioned iterator keeps iterating the old contents
+// until it seeks again. The concurrent writers that started before Clear may take effect on the
+// old contents, as if they happened before Clear.
+func (s *Int64Map[valueT]) Clear() {
+	if s.index != nil {
+		s.index.Lock()
+		defer s.index.Unlock()
+	}
+	if s.snap != nil {
+		s.snap.mu.RLock()
+		defer s.snap.mu.RUnlock()
 	}
+	atomic.StorePointer(&s.list, unsafe.Pointer(s.newList()))
 }
 
 // Len returns the length of this skipmap.
 func (s *Int64Map[valueT]) Len() int {
-	return int(atomic.LoadInt64(&s.length))
+	l := s.load()
+	return int(atomic.LoadInt64(&l.length))
 }
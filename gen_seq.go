@@ -0,0 +1,1129 @@
+// Code generated by gen.go; DO NOT EDIT.
+
+//go:build go1.23
+
+package skipmap
+
+import "iter"
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *OrderedMap[keyT, valueT]) All() iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *OrderedMap[keyT, valueT]) AllFrom(start keyT) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *OrderedMap[keyT, valueT]) AllBetween(lo, hi keyT, bounds Bounds) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *OrderedMap[keyT, valueT]) Keys() iter.Seq[keyT] {
+	return func(yield func(keyT) bool) {
+		s.Range(func(key keyT, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *OrderedMap[keyT, valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ keyT, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *OrderedMap[keyT, valueT]) Backward() iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *OrderedMap[keyT, valueT]) BackwardFrom(start keyT) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *OrderedMap[keyT, valueT]) BackwardBetween(lo, hi keyT, bounds Bounds) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *OrderedMapDesc[keyT, valueT]) All() iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *OrderedMapDesc[keyT, valueT]) AllFrom(start keyT) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *OrderedMapDesc[keyT, valueT]) AllBetween(lo, hi keyT, bounds Bounds) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *OrderedMapDesc[keyT, valueT]) Keys() iter.Seq[keyT] {
+	return func(yield func(keyT) bool) {
+		s.Range(func(key keyT, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *OrderedMapDesc[keyT, valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ keyT, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *OrderedMapDesc[keyT, valueT]) Backward() iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *OrderedMapDesc[keyT, valueT]) BackwardFrom(start keyT) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *OrderedMapDesc[keyT, valueT]) BackwardBetween(lo, hi keyT, bounds Bounds) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *FuncMap[keyT, valueT]) All() iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *FuncMap[keyT, valueT]) AllFrom(start keyT) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *FuncMap[keyT, valueT]) AllBetween(lo, hi keyT, bounds Bounds) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *FuncMap[keyT, valueT]) Keys() iter.Seq[keyT] {
+	return func(yield func(keyT) bool) {
+		s.Range(func(key keyT, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *FuncMap[keyT, valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ keyT, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *FuncMap[keyT, valueT]) Backward() iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *FuncMap[keyT, valueT]) BackwardFrom(start keyT) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *FuncMap[keyT, valueT]) BackwardBetween(lo, hi keyT, bounds Bounds) iter.Seq2[keyT, valueT] {
+	return func(yield func(keyT, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *StringMap[valueT]) All() iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *StringMap[valueT]) AllFrom(start string) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *StringMap[valueT]) AllBetween(lo, hi string, bounds Bounds) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *StringMap[valueT]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		s.Range(func(key string, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *StringMap[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ string, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *StringMap[valueT]) Backward() iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *StringMap[valueT]) BackwardFrom(start string) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *StringMap[valueT]) BackwardBetween(lo, hi string, bounds Bounds) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *StringMapDesc[valueT]) All() iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *StringMapDesc[valueT]) AllFrom(start string) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *StringMapDesc[valueT]) AllBetween(lo, hi string, bounds Bounds) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *StringMapDesc[valueT]) Keys() iter.Seq[string] {
+	return func(yield func(string) bool) {
+		s.Range(func(key string, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *StringMapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ string, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *StringMapDesc[valueT]) Backward() iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *StringMapDesc[valueT]) BackwardFrom(start string) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *StringMapDesc[valueT]) BackwardBetween(lo, hi string, bounds Bounds) iter.Seq2[string, valueT] {
+	return func(yield func(string, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *IntMap[valueT]) All() iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *IntMap[valueT]) AllFrom(start int) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *IntMap[valueT]) AllBetween(lo, hi int, bounds Bounds) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *IntMap[valueT]) Keys() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		s.Range(func(key int, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *IntMap[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ int, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *IntMap[valueT]) Backward() iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *IntMap[valueT]) BackwardFrom(start int) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *IntMap[valueT]) BackwardBetween(lo, hi int, bounds Bounds) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *IntMapDesc[valueT]) All() iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *IntMapDesc[valueT]) AllFrom(start int) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *IntMapDesc[valueT]) AllBetween(lo, hi int, bounds Bounds) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *IntMapDesc[valueT]) Keys() iter.Seq[int] {
+	return func(yield func(int) bool) {
+		s.Range(func(key int, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *IntMapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ int, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *IntMapDesc[valueT]) Backward() iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *IntMapDesc[valueT]) BackwardFrom(start int) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *IntMapDesc[valueT]) BackwardBetween(lo, hi int, bounds Bounds) iter.Seq2[int, valueT] {
+	return func(yield func(int, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Int64Map[valueT]) All() iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Int64Map[valueT]) AllFrom(start int64) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Int64Map[valueT]) AllBetween(lo, hi int64, bounds Bounds) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Int64Map[valueT]) Keys() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		s.Range(func(key int64, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Int64Map[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ int64, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Int64Map[valueT]) Backward() iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Int64Map[valueT]) BackwardFrom(start int64) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Int64Map[valueT]) BackwardBetween(lo, hi int64, bounds Bounds) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Int64MapDesc[valueT]) All() iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Int64MapDesc[valueT]) AllFrom(start int64) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Int64MapDesc[valueT]) AllBetween(lo, hi int64, bounds Bounds) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Int64MapDesc[valueT]) Keys() iter.Seq[int64] {
+	return func(yield func(int64) bool) {
+		s.Range(func(key int64, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Int64MapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ int64, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Int64MapDesc[valueT]) Backward() iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Int64MapDesc[valueT]) BackwardFrom(start int64) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Int64MapDesc[valueT]) BackwardBetween(lo, hi int64, bounds Bounds) iter.Seq2[int64, valueT] {
+	return func(yield func(int64, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Int32Map[valueT]) All() iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Int32Map[valueT]) AllFrom(start int32) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Int32Map[valueT]) AllBetween(lo, hi int32, bounds Bounds) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Int32Map[valueT]) Keys() iter.Seq[int32] {
+	return func(yield func(int32) bool) {
+		s.Range(func(key int32, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Int32Map[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ int32, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Int32Map[valueT]) Backward() iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Int32Map[valueT]) BackwardFrom(start int32) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Int32Map[valueT]) BackwardBetween(lo, hi int32, bounds Bounds) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Int32MapDesc[valueT]) All() iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Int32MapDesc[valueT]) AllFrom(start int32) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Int32MapDesc[valueT]) AllBetween(lo, hi int32, bounds Bounds) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Int32MapDesc[valueT]) Keys() iter.Seq[int32] {
+	return func(yield func(int32) bool) {
+		s.Range(func(key int32, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Int32MapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ int32, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Int32MapDesc[valueT]) Backward() iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Int32MapDesc[valueT]) BackwardFrom(start int32) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Int32MapDesc[valueT]) BackwardBetween(lo, hi int32, bounds Bounds) iter.Seq2[int32, valueT] {
+	return func(yield func(int32, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Uint64Map[valueT]) All() iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Uint64Map[valueT]) AllFrom(start uint64) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Uint64Map[valueT]) AllBetween(lo, hi uint64, bounds Bounds) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Uint64Map[valueT]) Keys() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		s.Range(func(key uint64, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Uint64Map[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ uint64, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Uint64Map[valueT]) Backward() iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Uint64Map[valueT]) BackwardFrom(start uint64) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Uint64Map[valueT]) BackwardBetween(lo, hi uint64, bounds Bounds) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Uint64MapDesc[valueT]) All() iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Uint64MapDesc[valueT]) AllFrom(start uint64) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Uint64MapDesc[valueT]) AllBetween(lo, hi uint64, bounds Bounds) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Uint64MapDesc[valueT]) Keys() iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		s.Range(func(key uint64, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Uint64MapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ uint64, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Uint64MapDesc[valueT]) Backward() iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Uint64MapDesc[valueT]) BackwardFrom(start uint64) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Uint64MapDesc[valueT]) BackwardBetween(lo, hi uint64, bounds Bounds) iter.Seq2[uint64, valueT] {
+	return func(yield func(uint64, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Uint32Map[valueT]) All() iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Uint32Map[valueT]) AllFrom(start uint32) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Uint32Map[valueT]) AllBetween(lo, hi uint32, bounds Bounds) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Uint32Map[valueT]) Keys() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		s.Range(func(key uint32, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Uint32Map[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ uint32, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Uint32Map[valueT]) Backward() iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Uint32Map[valueT]) BackwardFrom(start uint32) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Uint32Map[valueT]) BackwardBetween(lo, hi uint32, bounds Bounds) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *Uint32MapDesc[valueT]) All() iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *Uint32MapDesc[valueT]) AllFrom(start uint32) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *Uint32MapDesc[valueT]) AllBetween(lo, hi uint32, bounds Bounds) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *Uint32MapDesc[valueT]) Keys() iter.Seq[uint32] {
+	return func(yield func(uint32) bool) {
+		s.Range(func(key uint32, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *Uint32MapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ uint32, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *Uint32MapDesc[valueT]) Backward() iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *Uint32MapDesc[valueT]) BackwardFrom(start uint32) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *Uint32MapDesc[valueT]) BackwardBetween(lo, hi uint32, bounds Bounds) iter.Seq2[uint32, valueT] {
+	return func(yield func(uint32, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *UintMap[valueT]) All() iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *UintMap[valueT]) AllFrom(start uint) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *UintMap[valueT]) AllBetween(lo, hi uint, bounds Bounds) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *UintMap[valueT]) Keys() iter.Seq[uint] {
+	return func(yield func(uint) bool) {
+		s.Range(func(key uint, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *UintMap[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ uint, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *UintMap[valueT]) Backward() iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *UintMap[valueT]) BackwardFrom(start uint) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *UintMap[valueT]) BackwardBetween(lo, hi uint, bounds Bounds) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
+
+// All returns an iterator over all keys and values in the skipmap, in the order of Range.
+// It has the same consistency guarantees as Range.
+func (s *UintMapDesc[valueT]) All() iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.Range(yield)
+	}
+}
+
+// AllFrom returns an iterator over the keys and values starting from the first key greater than
+// or equal to start, in the order of Range. It has the same consistency guarantees as Range.
+func (s *UintMapDesc[valueT]) AllFrom(start uint) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeFrom(start, yield)
+	}
+}
+
+// AllBetween returns an iterator over the keys and values between lo and hi, in the order of Range.
+// See RangeBetween for details.
+func (s *UintMapDesc[valueT]) AllBetween(lo, hi uint, bounds Bounds) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeBetween(lo, hi, bounds, yield)
+	}
+}
+
+// Keys returns an iterator over all keys in the skipmap, in the order of Range.
+func (s *UintMapDesc[valueT]) Keys() iter.Seq[uint] {
+	return func(yield func(uint) bool) {
+		s.Range(func(key uint, _ valueT) bool {
+			return yield(key)
+		})
+	}
+}
+
+// Values returns an iterator over all values in the skipmap, in the order of Range.
+func (s *UintMapDesc[valueT]) Values() iter.Seq[valueT] {
+	return func(yield func(valueT) bool) {
+		s.Range(func(_ uint, value valueT) bool {
+			return yield(value)
+		})
+	}
+}
+
+// Backward returns an iterator over all keys and values in the skipmap, in the order of RangeReverse.
+// It has the same consistency guarantees and costs as RangeReverse.
+func (s *UintMapDesc[valueT]) Backward() iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeReverse(yield)
+	}
+}
+
+// BackwardFrom returns an iterator over the keys and values starting from the last key less than
+// or equal to start, in the order of RangeReverse. See RangeReverseFrom for details.
+func (s *UintMapDesc[valueT]) BackwardFrom(start uint) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeReverseFrom(start, yield)
+	}
+}
+
+// BackwardBetween returns an iterator over the keys and values between lo and hi, in the order of
+// RangeReverse. See RangeReverseBetween for details.
+func (s *UintMapDesc[valueT]) BackwardBetween(lo, hi uint, bounds Bounds) iter.Seq2[uint, valueT] {
+	return func(yield func(uint, valueT) bool) {
+		s.RangeReverseBetween(lo, hi, bounds, yield)
+	}
+}
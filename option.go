@@ -0,0 +1,55 @@
+package skipmap
+
+// Option configures a skipmap, it is passed to the constructors such as New and NewInt.
+type Option func(*config)
+
+type config struct {
+	index    bool
+	snapshot bool
+	txn      bool
+}
+
+func newConfig(opts []Option) config {
+	var cfg config
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	return cfg
+}
+
+// WithIndex makes the skipmap maintain the span counts of every level,
+// so that Rank and At can be answered in O(log n) instead of O(n).
+//
+// The span counts are not maintained per node: every write that inserts or
+// deletes a key (e.g. Store, Delete and Compute) holds a skipmap-wide lock,
+// so the writes to an indexed skipmap are fully serialized and do not scale
+// with the number of goroutines. Rank, At and CountRange hold the lock in
+// read mode, while Load and Range do not take it and are still wait-free.
+func WithIndex() Option {
+	return func(c *config) {
+		c.index = true
+	}
+}
+
+// WithSnapshot makes the skipmap support Snapshot, which returns a read-only view of
+// the skipmap at the time it is taken.
+//
+// The skipmap keeps the old values and the deleted keys needed by the live snapshots,
+// so the writers allocate more and take a skipmap-wide lock in read mode, which is
+// taken in write mode by Snapshot and Close of the snapshots.
+func WithSnapshot() Option {
+	return func(c *config) {
+		c.snapshot = true
+	}
+}
+
+// WithTxn makes the skipmap support Update, which writes several keys in a transaction atomically.
+//
+// The readers of the skipmap (e.g. Load and Range) wait for the transactions being committed
+// on the keys they meet, so they are no longer wait-free. The skipmaps created without it
+// never wait in the readers.
+func WithTxn() Option {
+	return func(c *config) {
+		c.txn = true
+	}
+}
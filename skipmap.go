@@ -12,68 +12,38 @@ import "math"
 // Note that the less function requires a strict weak ordering,
 // see https://en.wikipedia.org/wiki/Weak_ordering#Strict_weak_orderings,
 // or undefined behavior will happen.
-func NewFunc[keyT any, valueT any](less func(a, b keyT) bool) *FuncMap[keyT, valueT] {
-	var (
-		t1 keyT
-		t2 valueT
-	)
-	h := newFuncNode(t1, t2, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &FuncMap[keyT, valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-		less:         less,
-	}
+func NewFunc[keyT any, valueT any](less func(a, b keyT) bool, opts ...Option) *FuncMap[keyT, valueT] {
+	s := &FuncMap[keyT, valueT]{less: less}
+	s.init(opts)
+	return s
 }
 
 // New returns an empty skipmap in ascending order.
-func New[keyT ordered, valueT any]() *OrderedMap[keyT, valueT] {
-	var (
-		t1 keyT
-		t2 valueT
-	)
-	h := newOrderedNode(t1, t2, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &OrderedMap[keyT, valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func New[keyT ordered, valueT any](opts ...Option) *OrderedMap[keyT, valueT] {
+	s := new(OrderedMap[keyT, valueT])
+	s.init(opts)
+	return s
 }
 
 // NewDesc returns an empty skipmap in descending order.
-func NewDesc[keyT ordered, valueT any]() *OrderedMapDesc[keyT, valueT] {
-	var (
-		t1 keyT
-		t2 valueT
-	)
-	h := newOrderedNodeDesc(t1, t2, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &OrderedMapDesc[keyT, valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewDesc[keyT ordered, valueT any](opts ...Option) *OrderedMapDesc[keyT, valueT] {
+	s := new(OrderedMapDesc[keyT, valueT])
+	s.init(opts)
+	return s
 }
 
 // NewString returns an empty skipmap in ascending order.
-func NewString[valueT any]() *StringMap[valueT] {
-	var t valueT
-	h := newStringNode("", t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &StringMap[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewString[valueT any](opts ...Option) *StringMap[valueT] {
+	s := new(StringMap[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewStringDesc returns an empty skipmap in descending order.
-func NewStringDesc[valueT any]() *StringMapDesc[valueT] {
-	var t valueT
-	h := newStringNodeDesc("", t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &StringMapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewStringDesc[valueT any](opts ...Option) *StringMapDesc[valueT] {
+	s := new(StringMapDesc[valueT])
+	s.init(opts)
+	return s
 }
 
 func isNaNf32(x float32) bool {
@@ -81,161 +51,113 @@ func isNaNf32(x float32) bool {
 }
 
 // NewFloat32 returns an empty skipmap in ascending order.
-func NewFloat32[valueT any]() *FuncMap[float32, valueT] {
+func NewFloat32[valueT any](opts ...Option) *FuncMap[float32, valueT] {
 	return NewFunc[float32, valueT](func(a, b float32) bool {
 		return a < b || (isNaNf32(a) && !isNaNf32(b))
-	})
+	}, opts...)
 }
 
 // NewFloat32Desc returns an empty skipmap in descending order.
-func NewFloat32Desc[valueT any]() *FuncMap[float32, valueT] {
+func NewFloat32Desc[valueT any](opts ...Option) *FuncMap[float32, valueT] {
 	return NewFunc[float32, valueT](func(a, b float32) bool {
 		return a > b || (isNaNf32(a) && !isNaNf32(b))
-	})
+	}, opts...)
 }
 
 // NewFloat64 returns an empty skipmap in ascending order.
-func NewFloat64[valueT any]() *FuncMap[float64, valueT] {
+func NewFloat64[valueT any](opts ...Option) *FuncMap[float64, valueT] {
 	return NewFunc[float64, valueT](func(a, b float64) bool {
 		return a < b || (math.IsNaN(a) && !math.IsNaN(b))
-	})
+	}, opts...)
 }
 
 // NewFloat64Desc returns an empty skipmap in descending order.
-func NewFloat64Desc[valueT any]() *FuncMap[float64, valueT] {
+func NewFloat64Desc[valueT any](opts ...Option) *FuncMap[float64, valueT] {
 	return NewFunc[float64, valueT](func(a, b float64) bool {
 		return a > b || (math.IsNaN(a) && !math.IsNaN(b))
-	})
+	}, opts...)
 }
 
 // NewInt returns an empty skipmap in ascending order.
-func NewInt[valueT any]() *IntMap[valueT] {
-	var t valueT
-	h := newIntNode(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &IntMap[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewInt[valueT any](opts ...Option) *IntMap[valueT] {
+	s := new(IntMap[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewIntDesc returns an empty skipmap in descending order.
-func NewIntDesc[valueT any]() *IntMapDesc[valueT] {
-	var t valueT
-	h := newIntNodeDesc(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &IntMapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewIntDesc[valueT any](opts ...Option) *IntMapDesc[valueT] {
+	s := new(IntMapDesc[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewInt64 returns an empty skipmap in ascending order.
-func NewInt64[valueT any]() *Int64Map[valueT] {
-	var t valueT
-	h := newInt64Node(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Int64Map[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewInt64[valueT any](opts ...Option) *Int64Map[valueT] {
+	s := new(Int64Map[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewInt64Desc returns an empty skipmap in descending order.
-func NewInt64Desc[valueT any]() *Int64MapDesc[valueT] {
-	var t valueT
-	h := newInt64NodeDesc(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Int64MapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewInt64Desc[valueT any](opts ...Option) *Int64MapDesc[valueT] {
+	s := new(Int64MapDesc[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewInt32 returns an empty skipmap in ascending order.
-func NewInt32[valueT any]() *Int32Map[valueT] {
-	var t valueT
-	h := newInt32Node(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Int32Map[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewInt32[valueT any](opts ...Option) *Int32Map[valueT] {
+	s := new(Int32Map[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewInt32Desc returns an empty skipmap in descending order.
-func NewInt32Desc[valueT any]() *Int32MapDesc[valueT] {
-	var t valueT
-	h := newInt32NodeDesc(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Int32MapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewInt32Desc[valueT any](opts ...Option) *Int32MapDesc[valueT] {
+	s := new(Int32MapDesc[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewUint64 returns an empty skipmap in ascending order.
-func NewUint64[valueT any]() *Uint64Map[valueT] {
-	var t valueT
-	h := newUint64Node(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Uint64Map[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewUint64[valueT any](opts ...Option) *Uint64Map[valueT] {
+	s := new(Uint64Map[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewUint64Desc returns an empty skipmap in descending order.
-func NewUint64Desc[valueT any]() *Uint64MapDesc[valueT] {
-	var t valueT
-	h := newUint64NodeDesc(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Uint64MapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewUint64Desc[valueT any](opts ...Option) *Uint64MapDesc[valueT] {
+	s := new(Uint64MapDesc[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewUint32 returns an empty skipmap in ascending order.
-func NewUint32[valueT any]() *Uint32Map[valueT] {
-	var t valueT
-	h := newUint32Node(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Uint32Map[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewUint32[valueT any](opts ...Option) *Uint32Map[valueT] {
+	s := new(Uint32Map[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewUint32Desc returns an empty skipmap in descending order.
-func NewUint32Desc[valueT any]() *Uint32MapDesc[valueT] {
-	var t valueT
-	h := newUint32NodeDesc(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &Uint32MapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewUint32Desc[valueT any](opts ...Option) *Uint32MapDesc[valueT] {
+	s := new(Uint32MapDesc[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewUint returns an empty skipmap in ascending order.
-func NewUint[valueT any]() *UintMap[valueT] {
-	var t valueT
-	h := newUintNode(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &UintMap[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewUint[valueT any](opts ...Option) *UintMap[valueT] {
+	s := new(UintMap[valueT])
+	s.init(opts)
+	return s
 }
 
 // NewUintDesc returns an empty skipmap in descending order.
-func NewUintDesc[valueT any]() *UintMapDesc[valueT] {
-	var t valueT
-	h := newUintNodeDesc(0, t, maxLevel)
-	h.flags.SetTrue(fullyLinked)
-	return &UintMapDesc[valueT]{
-		header:       h,
-		highestLevel: defaultHighestLevel,
-	}
+func NewUintDesc[valueT any](opts ...Option) *UintMapDesc[valueT] {
+	s := new(UintMapDesc[valueT])
+	s.init(opts)
+	return s
 }
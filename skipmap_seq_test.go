@@ -0,0 +1,72 @@
+//go:build go1.23
+
+package skipmap
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSeq(t *testing.T) {
+	m := NewIntDesc[string]()
+	for i := 0; i < 10; i++ {
+		m.Store(i, string(rune('a'+i)))
+	}
+
+	var keys []int
+	var values []string
+	for k, v := range m.All() {
+		keys = append(keys, k)
+		values = append(values, v)
+	}
+	if !reflect.DeepEqual(keys, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}) || values[0] != "j" || values[9] != "a" {
+		t.Fatal("invalid", keys, values)
+	}
+
+	keys = keys[:0]
+	for k := range m.Keys() {
+		if k == 5 {
+			break
+		}
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []int{9, 8, 7, 6}) {
+		t.Fatal("invalid", keys)
+	}
+
+	values = values[:0]
+	for v := range m.Values() {
+		values = append(values, v)
+	}
+	if len(values) != 10 || values[0] != "j" {
+		t.Fatal("invalid", values)
+	}
+
+	keys = keys[:0]
+	for k := range m.Backward() {
+		keys = append(keys, k)
+	}
+	if !reflect.DeepEqual(keys, []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}) {
+		t.Fatal("invalid", keys)
+	}
+
+	collect := func(seq func(yield func(int, string) bool)) []int {
+		var res []int
+		for k := range seq {
+			res = append(res, k)
+		}
+		return res
+	}
+	if got := collect(m.AllFrom(6)); !reflect.DeepEqual(got, []int{6, 5, 4, 3, 2, 1, 0}) {
+		t.Fatal("invalid", got)
+	}
+	if got := collect(m.AllBetween(6, 3, ExcludeHi)); !reflect.DeepEqual(got, []int{6, 5, 4}) {
+		t.Fatal("invalid", got)
+	}
+	if got := collect(m.BackwardFrom(6)); !reflect.DeepEqual(got, []int{6, 7, 8, 9}) {
+		t.Fatal("invalid", got)
+	}
+	if got := collect(m.BackwardBetween(6, 3, ExcludeLo)); !reflect.DeepEqual(got, []int{3, 4, 5}) {
+		t.Fatal("invalid", got)
+	}
+}
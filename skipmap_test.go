@@ -1,14 +1,18 @@
 package skipmap
 
 import (
+	"errors"
 	"math"
 	"math/rand"
 	"reflect"
 	"runtime"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/zhangyunhao116/fastrand"
 )
@@ -20,6 +24,13 @@ func TestTyped(t *testing.T) {
 	testSyncMapSuiteInt64(t, func() anyskipmap[int64] { return NewInt64[any]() })
 }
 
+func TestIndexed(t *testing.T) {
+	testSkipMapInt(t, func() anyskipmap[int] { return NewInt[any](WithIndex()) })
+	testSkipMapIntDesc(t, func() anyskipmap[int] { return NewIntDesc[any](WithIndex()) })
+	testSkipMapString(t, func() anyskipmap[string] { return New[string, any](WithIndex()) })
+	testSyncMapSuiteInt64(t, func() anyskipmap[int64] { return NewInt64[any](WithIndex()) })
+}
+
 func TestOrdered(t *testing.T) {
 	testSkipMapInt(t, func() anyskipmap[int] { return New[int, any]() })
 	testSkipMapIntDesc(t, func() anyskipmap[int] { return NewDesc[int, any]() })
@@ -38,6 +49,11 @@ type anyskipmap[T any] interface {
 	LoadAndDelete(key T) (any, bool)
 	LoadOrStore(key T, value any) (any, bool)
 	LoadOrStoreLazy(key T, f func() any) (any, bool)
+	Swap(key T, value any) (any, bool)
+	CompareAndSwap(key T, old, new any) bool
+	CompareAndDelete(key T, old any) bool
+	Compute(key T, f func(old any, loaded bool) (any, Op)) (any, bool)
+	Clear()
 	Range(f func(key T, value any) bool)
 	Len() int
 }
@@ -181,6 +197,13 @@ func testSkipMapInt(t *testing.T, newset func() anyskipmap[int]) {
 		} else if rd == 3 {
 			m1.Delete(r1)
 			m2.Delete(r1)
+		} else if rd == 5 {
+			v1, ok1 = m1.Load(r1)
+			m1.Store(r1, r2)
+			v2, ok2 = m2.Swap(r1, r2)
+			if ok1 != ok2 || v1 != v2 {
+				t.Fatal(rd, v1, ok1, v2, ok2, "input -> ", r1, r2)
+			}
 		} else if rd == 4 {
 			m2.Range(func(key int, value interface{}) bool {
 				v, ok := m1.Load(key)
@@ -276,6 +299,199 @@ func testSkipMapInt(t *testing.T, newset func() anyskipmap[int]) {
 	if tmpmap.Len() != 1 {
 		t.Fatal("only one value can be returned from LoadOrStoreLazy")
 	}
+
+	// Correntness 6. (Swap)
+	// Every value is returned by Swap exactly once, except the last one stored.
+	mp = newset()
+	tmpmap = newset()
+	samekey = 123
+	added = 0
+	for i := 1; i < 1000; i++ {
+		wg.Add(1)
+		go func(i int) {
+			previous, loaded := mp.Swap(samekey, i)
+			if !loaded {
+				atomic.AddInt64(&added, 1)
+			} else if _, ok := tmpmap.LoadOrStore(previous.(int), nil); ok {
+				panic("invalid")
+			}
+			wg.Done()
+		}(i)
+	}
+	wg.Wait()
+	last, _ := mp.Load(samekey)
+	if _, ok := tmpmap.LoadOrStore(last.(int), nil); ok || added != 1 || tmpmap.Len() != 999 || mp.Len() != 1 {
+		t.Fatal("invalid Swap", added, tmpmap.Len(), mp.Len())
+	}
+
+	// Correntness 7. (CompareAndSwap and CompareAndDelete)
+	if mp.CompareAndSwap(456, nil, 1) || mp.CompareAndDelete(456, nil) {
+		t.Fatal("the key is not present")
+	}
+	mp = newset()
+	mp.Store(samekey, 0)
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			for j := 0; j < 100; {
+				v, _ := mp.Load(samekey)
+				if mp.CompareAndSwap(samekey, v, v.(int)+1) {
+					j++
+				}
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	if v, _ := mp.Load(samekey); v != 800 || mp.CompareAndDelete(samekey, 799) || !mp.CompareAndDelete(samekey, 800) || mp.Len() != 0 {
+		t.Fatal("invalid CompareAndSwap", v, mp.Len())
+	}
+	// Only one of CompareAndSwap and CompareAndDelete can succeed with the same old value.
+	for i := 0; i < 100; i++ {
+		mp.Store(i, 0)
+		added = 0
+		for j := 0; j < 4; j++ {
+			wg.Add(2)
+			go func(i int) {
+				if mp.CompareAndSwap(i, 0, 1) {
+					atomic.AddInt64(&added, 1)
+				}
+				wg.Done()
+			}(i)
+			go func(i int) {
+				if mp.CompareAndDelete(i, 0) {
+					atomic.AddInt64(&added, 1)
+				}
+				wg.Done()
+			}(i)
+		}
+		wg.Wait()
+		if added != 1 {
+			t.Fatal("only one CompareAndSwap or CompareAndDelete can succeed", i, added)
+		}
+	}
+
+	// Correntness 8. (Compute)
+	mp = newset()
+	incr := func(old any, loaded bool) (any, Op) {
+		if !loaded {
+			return 1, OpStore
+		}
+		return old.(int) + 1, OpStore
+	}
+	if v, ok := mp.Compute(1, func(old any, loaded bool) (any, Op) { return 1, OpKeep }); ok || v != nil || mp.Len() != 0 {
+		t.Fatal("invalid Compute", v, ok)
+	}
+	if v, ok := mp.Compute(1, incr); !ok || v != 1 || mp.Len() != 1 {
+		t.Fatal("invalid Compute", v, ok)
+	}
+	if v, ok := mp.Compute(1, incr); !ok || v != 2 {
+		t.Fatal("invalid Compute", v, ok)
+	}
+	if v, ok := mp.Compute(1, func(old any, loaded bool) (any, Op) { return nil, OpKeep }); !ok || v != 2 {
+		t.Fatal("invalid Compute", v, ok)
+	}
+	if v, ok := mp.Compute(1, func(old any, loaded bool) (any, Op) { return nil, OpDelete }); ok || v != nil || mp.Len() != 0 {
+		t.Fatal("invalid Compute", v, ok)
+	}
+	// The increments are neither lost nor duplicated with concurrent deletions.
+	var removed int64
+	for i := 0; i < 8; i++ {
+		wg.Add(2)
+		go func() {
+			for j := 0; j < 1000; j++ {
+				mp.Compute(j%10, incr)
+			}
+			wg.Done()
+		}()
+		go func() {
+			for j := 0; j < 100; j++ {
+				if v, ok := mp.LoadAndDelete(j % 10); ok {
+					atomic.AddInt64(&removed, int64(v.(int)))
+				}
+				mp.Compute(j%10, func(old any, loaded bool) (any, Op) {
+					if loaded && old.(int) > 5 {
+						// No lock-free writers here, so f is called only once.
+						atomic.AddInt64(&removed, int64(old.(int)))
+						return nil, OpDelete
+					}
+					return nil, OpKeep
+				})
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	mp.Range(func(key int, value any) bool {
+		removed += int64(value.(int))
+		return true
+	})
+	if removed != 8000 {
+		t.Fatal("invalid Compute", removed)
+	}
+
+	// Correntness 9. (Clear)
+	mp.Clear()
+	if mp.Len() != 0 {
+		t.Fatal("invalid Clear", mp.Len())
+	}
+	mp.Range(func(key int, value any) bool {
+		t.Fatal("invalid Clear", key)
+		return false
+	})
+	var stop int32
+	wg.Add(1)
+	go func() {
+		for atomic.LoadInt32(&stop) == 0 {
+			mp.Clear()
+			runtime.Gosched()
+		}
+		wg.Done()
+	}()
+	var wg2 sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg2.Add(2)
+		go func() {
+			for j := 0; j < 10000; j++ {
+				mp.Store(j%100, j)
+				mp.Delete((j + 50) % 100)
+			}
+			wg2.Done()
+		}()
+		go func() {
+			for j := 0; j < 100; j++ {
+				prev := -1
+				mp.Range(func(key int, value any) bool {
+					if key <= prev {
+						panic("invalid Range during Clear")
+					}
+					prev = key
+					return true
+				})
+				mp.Load(j)
+			}
+			wg2.Done()
+		}()
+	}
+	wg2.Wait()
+	atomic.StoreInt32(&stop, 1)
+	wg.Wait()
+	n := 0
+	mp.Range(func(key int, value any) bool {
+		n++
+		return true
+	})
+	if n != mp.Len() {
+		t.Fatal("invalid Clear", n, mp.Len())
+	}
+	mp.Clear()
+	if _, ok := mp.Load(1); ok || mp.Len() != 0 {
+		t.Fatal("invalid Clear")
+	}
+	mp.Store(1, 1)
+	if v, ok := mp.Load(1); !ok || v != 1 || mp.Len() != 1 {
+		t.Fatal("invalid Clear")
+	}
 }
 
 func testSkipMapIntDesc(t *testing.T, newset func() anyskipmap[int]) {
@@ -565,3 +781,1810 @@ func TestFloatMap(t *testing.T) {
 	checkEqual32(m32r, asc32)
 	checkEqual32(m32dr, desc32)
 }
+
+type nearestskipmap[T any] interface {
+	Store(key T, value any)
+	Delete(key T) bool
+	Floor(key T) (T, any, bool)
+	Ceiling(key T) (T, any, bool)
+	Lower(key T) (T, any, bool)
+	Higher(key T) (T, any, bool)
+}
+
+func TestNearest(t *testing.T) {
+	asc := func(a, b int) bool { return a < b }
+	desc := func(a, b int) bool { return a > b }
+	testNearest(t, NewInt[any](), asc)
+	testNearest(t, NewIntDesc[any](), desc)
+	testNearest(t, New[int, any](), asc)
+	testNearest(t, NewDesc[int, any](), desc)
+	testNearest(t, NewFunc[int, any](asc), asc)
+	testNearest(t, NewFunc[int, any](desc), desc)
+}
+
+func testNearest(t *testing.T, m nearestskipmap[int], less func(a, b int) bool) {
+	if _, _, ok := m.Floor(0); ok {
+		t.Fatal("invalid")
+	}
+	if _, _, ok := m.Higher(0); ok {
+		t.Fatal("invalid")
+	}
+	// Store the even numbers in [0, 100), and delete some of them.
+	exist := make(map[int]bool)
+	for i := 0; i < 100; i += 2 {
+		m.Store(i, i)
+		exist[i] = true
+	}
+	for i := 0; i < 100; i += 10 {
+		m.Delete(i)
+		delete(exist, i)
+	}
+	check := func(name string, key int, got int, v any, ok bool, match func(k int) bool, better func(a, b int) bool) {
+		want, found := 0, false
+		for k := range exist {
+			if match(k) && (!found || better(k, want)) {
+				want, found = k, true
+			}
+		}
+		if ok != found || (ok && (got != want || v != want)) {
+			t.Fatal(name, key, got, v, ok, want, found)
+		}
+	}
+	for key := -5; key < 105; key++ {
+		k, v, ok := m.Floor(key)
+		check("Floor", key, k, v, ok, func(k int) bool { return !less(key, k) }, func(a, b int) bool { return less(b, a) })
+		k, v, ok = m.Ceiling(key)
+		check("Ceiling", key, k, v, ok, func(k int) bool { return !less(k, key) }, less)
+		k, v, ok = m.Lower(key)
+		check("Lower", key, k, v, ok, func(k int) bool { return less(k, key) }, func(a, b int) bool { return less(b, a) })
+		k, v, ok = m.Higher(key)
+		check("Higher", key, k, v, ok, func(k int) bool { return less(key, k) }, less)
+	}
+}
+
+type minmaxskipmap[T any] interface {
+	Store(key T, value any)
+	Delete(key T) bool
+	Min() (T, any, bool)
+	Max() (T, any, bool)
+}
+
+func TestMinMax(t *testing.T) {
+	testMinMax(t, NewInt[any](), 0, 99)
+	testMinMax(t, NewIntDesc[any](), 99, 0)
+	testMinMax(t, New[int, any](), 0, 99)
+	testMinMax(t, NewDesc[int, any](), 99, 0)
+	testMinMax(t, NewFunc[int, any](func(a, b int) bool { return a < b }), 0, 99)
+}
+
+func testMinMax(t *testing.T, m minmaxskipmap[int], first, last int) {
+	if _, _, ok := m.Min(); ok {
+		t.Fatal("invalid")
+	}
+	if _, _, ok := m.Max(); ok {
+		t.Fatal("invalid")
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			m.Store(i, i)
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	step := 1
+	if first > last {
+		step = -1
+	}
+	for i := 0; i < 10; i++ {
+		k, v, ok := m.Min()
+		if !ok || k != first || v != first {
+			t.Fatal("invalid", k, v, ok, first)
+		}
+		k, v, ok = m.Max()
+		if !ok || k != last || v != last {
+			t.Fatal("invalid", k, v, ok, last)
+		}
+		m.Delete(first)
+		m.Delete(last)
+		first += step
+		last -= step
+	}
+}
+
+type boundedskipmap[T any] interface {
+	Store(key T, value any)
+	Delete(key T) bool
+	RangeFrom(start T, f func(key T, value any) bool)
+	RangeBetween(lo, hi T, bounds Bounds, f func(key T, value any) bool)
+	RangeReverse(f func(key T, value any) bool)
+	RangeReverseFrom(start T, f func(key T, value any) bool)
+	RangeReverseBetween(lo, hi T, bounds Bounds, f func(key T, value any) bool)
+}
+
+func TestRangeBounded(t *testing.T) {
+	asc := func(a, b int) bool { return a < b }
+	desc := func(a, b int) bool { return a > b }
+	testRangeBounded(t, NewInt[any](), asc)
+	testRangeBounded(t, NewIntDesc[any](), desc)
+	testRangeBounded(t, New[int, any](), asc)
+	testRangeBounded(t, NewDesc[int, any](), desc)
+	testRangeBounded(t, NewFunc[int, any](asc), asc)
+}
+
+func testRangeBounded(t *testing.T, m boundedskipmap[int], less func(a, b int) bool) {
+	var all []int // all keys in the map order
+	for i := 0; i < 100; i += 2 {
+		m.Store(i, i)
+	}
+	m.Delete(50)
+	for i := 0; i < 100; i += 2 {
+		if i != 50 {
+			all = append(all, i)
+		}
+	}
+	if less(1, 0) {
+		for i, j := 0, len(all)-1; i < j; i, j = i+1, j-1 {
+			all[i], all[j] = all[j], all[i]
+		}
+	}
+	collect := func(f func(func(key int, value any) bool)) []int {
+		var res []int
+		f(func(key int, value any) bool {
+			if key != value {
+				t.Fatal("invalid value", key, value)
+			}
+			res = append(res, key)
+			return true
+		})
+		return res
+	}
+	filter := func(match func(k int) bool) []int {
+		var res []int
+		for _, k := range all {
+			if match(k) {
+				res = append(res, k)
+			}
+		}
+		return res
+	}
+	reverse := func(a []int) []int {
+		var res []int
+		for i := len(a) - 1; i >= 0; i-- {
+			res = append(res, a[i])
+		}
+		return res
+	}
+	if got := collect(m.RangeReverse); !reflect.DeepEqual(got, reverse(all)) {
+		t.Fatal("RangeReverse", got)
+	}
+	for start := -3; start < 103; start += 3 {
+		got := collect(func(f func(key int, value any) bool) { m.RangeFrom(start, f) })
+		want := filter(func(k int) bool { return !less(k, start) })
+		if !reflect.DeepEqual(got, want) {
+			t.Fatal("RangeFrom", start, got, want)
+		}
+		got = collect(func(f func(key int, value any) bool) { m.RangeReverseFrom(start, f) })
+		want = reverse(filter(func(k int) bool { return !less(start, k) }))
+		if !reflect.DeepEqual(got, want) {
+			t.Fatal("RangeReverseFrom", start, got, want)
+		}
+	}
+	for _, bounds := range []Bounds{Closed, ExcludeLo, ExcludeHi, Open} {
+		for lo := -3; lo < 103; lo += 7 {
+			for hi := -3; hi < 103; hi += 5 {
+				got := collect(func(f func(key int, value any) bool) { m.RangeBetween(lo, hi, bounds, f) })
+				want := filter(func(k int) bool {
+					if less(k, lo) || (bounds&ExcludeLo != 0 && k == lo) {
+						return false
+					}
+					return !less(hi, k) && !(bounds&ExcludeHi != 0 && k == hi)
+				})
+				if !reflect.DeepEqual(got, want) {
+					t.Fatal("RangeBetween", lo, hi, bounds, got, want)
+				}
+				got = collect(func(f func(key int, value any) bool) { m.RangeReverseBetween(lo, hi, bounds, f) })
+				if !reflect.DeepEqual(got, reverse(want)) {
+					t.Fatal("RangeReverseBetween", lo, hi, bounds, got, want)
+				}
+			}
+		}
+	}
+	// Stop the iteration early.
+	var count int
+	m.RangeBetween(all[0], all[len(all)-1], Closed, func(key int, value any) bool {
+		count++
+		return count < 3
+	})
+	if count != 3 {
+		t.Fatal("invalid", count)
+	}
+}
+
+func TestIterator(t *testing.T) {
+	m := NewInt[int]()
+	it := m.Iter()
+	if it.Valid() || it.SeekFirst() || it.SeekLast() || it.SeekGE(0) || it.SeekLE(0) {
+		t.Fatal("invalid")
+	}
+	for i := 0; i < 100; i += 2 {
+		m.Store(i, i+1)
+	}
+
+	var keys []int
+	for ok := it.SeekFirst(); ok; ok = it.Next() {
+		if it.Value() != it.Key()+1 {
+			t.Fatal("invalid value", it.Key(), it.Value())
+		}
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 50 || keys[0] != 0 || keys[49] != 98 || it.Valid() {
+		t.Fatal("invalid", keys)
+	}
+	keys = keys[:0]
+	for ok := it.SeekLast(); ok; ok = it.Prev() {
+		keys = append(keys, it.Key())
+	}
+	if len(keys) != 50 || keys[0] != 98 || keys[49] != 0 {
+		t.Fatal("invalid", keys)
+	}
+	if !it.SeekGE(31) || it.Key() != 32 || !it.SeekGE(32) || it.Key() != 32 || it.SeekGE(99) {
+		t.Fatal("invalid")
+	}
+	if !it.SeekLE(31) || it.Key() != 30 || !it.SeekLE(30) || it.Key() != 30 || it.SeekLE(-1) {
+		t.Fatal("invalid")
+	}
+
+	// Delete the current key, and insert a key right after it.
+	it.SeekGE(40)
+	m.Delete(40)
+	m.Store(41, 42)
+	if !it.Next() || it.Key() != 41 || !it.Prev() || it.Key() != 38 {
+		t.Fatal("invalid", it.Key())
+	}
+
+	// Concurrent.
+	m = NewInt[int]()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 10000; i++ {
+			k := int(fastrand.Uint32n(1000))
+			if fastrand.Uint32n(2) == 0 {
+				m.Store(k, k)
+			} else {
+				m.Delete(k)
+			}
+		}
+	}()
+	it = m.Iter()
+	for i := 0; i < 100; i++ {
+		prev := -1
+		for ok := it.SeekFirst(); ok; ok = it.Next() {
+			if it.Key() <= prev || it.Value() != it.Key() {
+				t.Fatal("invalid", prev, it.Key(), it.Value())
+			}
+			prev = it.Key()
+		}
+		prev = 1000
+		for ok := it.SeekLast(); ok; ok = it.Prev() {
+			if it.Key() >= prev {
+				t.Fatal("invalid", prev, it.Key())
+			}
+			prev = it.Key()
+		}
+	}
+	wg.Wait()
+}
+
+func TestPop(t *testing.T) {
+	m := NewInt[int]()
+	if _, _, ok := m.PopMin(); ok {
+		t.Fatal("invalid")
+	}
+	if _, _, ok := m.PopMax(); ok {
+		t.Fatal("invalid")
+	}
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	for i := 0; i < 5; i++ {
+		k, v, ok := m.PopMin()
+		if !ok || k != i || v != i {
+			t.Fatal("invalid", k, v, ok)
+		}
+		k, v, ok = m.PopMax()
+		if !ok || k != 9-i || v != 9-i {
+			t.Fatal("invalid", k, v, ok)
+		}
+	}
+	if m.Len() != 0 {
+		t.Fatal("invalid", m.Len())
+	}
+
+	// Concurrent, every key is popped exactly once.
+	const n = 10000
+	md := NewDesc[int, int]()
+	for i := 0; i < n; i++ {
+		md.Store(i, i)
+	}
+	var (
+		wg     sync.WaitGroup
+		popped [n]int32
+	)
+	for g := 0; g < 8; g++ {
+		g := g
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for {
+				var (
+					k  int
+					ok bool
+				)
+				if g%2 == 0 {
+					k, _, ok = md.PopMin()
+				} else {
+					k, _, ok = md.PopMax()
+				}
+				if !ok {
+					return
+				}
+				atomic.AddInt32(&popped[k], 1)
+			}
+		}()
+	}
+	wg.Wait()
+	for i := range popped {
+		if popped[i] != 1 {
+			t.Fatal("invalid", i, popped[i])
+		}
+	}
+	if md.Len() != 0 {
+		t.Fatal("invalid", md.Len())
+	}
+}
+
+func TestDeleteRange(t *testing.T) {
+	for _, bounds := range []Bounds{Closed, ExcludeLo, ExcludeHi, Open} {
+		m := NewInt[int]()
+		md := NewIntDesc[int]()
+		for i := 0; i < 100; i++ {
+			m.Store(i, i)
+			md.Store(i, i)
+		}
+		m.Delete(50)
+		md.Delete(50)
+		want := 40
+		if bounds&ExcludeLo != 0 {
+			want--
+		}
+		if bounds&ExcludeHi != 0 {
+			want--
+		}
+		if n := m.DeleteRange(30, 70, bounds); n != want || m.Len() != 99-want {
+			t.Fatal("invalid", bounds, n, m.Len())
+		}
+		if n := md.DeleteRange(70, 30, bounds); n != want || md.Len() != 99-want {
+			t.Fatal("invalid", bounds, n, md.Len())
+		}
+		for i := 0; i < 100; i++ {
+			_, ok := m.Load(i)
+			_, okd := md.Load(i)
+			expect := i != 50 && (i < 30 || i > 70 ||
+				i == 30 && bounds&ExcludeLo != 0 || i == 70 && bounds&ExcludeHi != 0)
+			expectd := i != 50 && (i < 30 || i > 70 ||
+				i == 70 && bounds&ExcludeLo != 0 || i == 30 && bounds&ExcludeHi != 0)
+			if ok != expect || okd != expectd {
+				t.Fatal("invalid", bounds, i, ok, okd)
+			}
+		}
+		if n := m.DeleteRange(70, 30, bounds); n != 0 {
+			t.Fatal("invalid", n)
+		}
+	}
+
+	// Concurrent.
+	m := NewInt[int]()
+	var (
+		wg      sync.WaitGroup
+		deleted int64
+	)
+	for i := 0; i < 10000; i++ {
+		m.Store(i, i)
+	}
+	for g := 0; g < 8; g++ {
+		g := g
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 100; i++ {
+				lo := int(fastrand.Uint32n(10000))
+				switch g % 4 {
+				case 0:
+					atomic.AddInt64(&deleted, int64(m.DeleteRange(lo, lo+100, Closed)))
+				case 1:
+					if m.Delete(lo) {
+						atomic.AddInt64(&deleted, 1)
+					}
+				default:
+					if _, _, ok := m.PopMin(); ok {
+						atomic.AddInt64(&deleted, 1)
+					}
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	var count int
+	m.Range(func(_, _ int) bool {
+		count++
+		return true
+	})
+	if count != m.Len() || count != 10000-int(deleted) {
+		t.Fatal("invalid", count, m.Len(), deleted)
+	}
+}
+
+type rankskipmap[T any] interface {
+	Store(key T, value any)
+	Delete(key T) bool
+	LoadOrStore(key T, value any) (any, bool)
+	LoadAndDelete(key T) (any, bool)
+	PopMin() (T, any, bool)
+	DeleteRange(lo, hi T, bounds Bounds) int
+	Range(f func(key T, value any) bool)
+	Rank(key T) (int, bool)
+	At(i int) (T, any, bool)
+	Len() int
+}
+
+func TestRank(t *testing.T) {
+	testRank(t, NewInt[any]())
+	testRank(t, NewInt[any](WithIndex()))
+	testRank(t, NewIntDesc[any](WithIndex()))
+	testRank(t, New[int, any](WithIndex()))
+	testRank(t, NewFunc[int, any](func(a, b int) bool { return a < b }, WithIndex()))
+}
+
+func testRank(t *testing.T, m rankskipmap[int]) {
+	check := func() {
+		var keys []int
+		m.Range(func(key int, _ any) bool {
+			keys = append(keys, key)
+			return true
+		})
+		if len(keys) != m.Len() {
+			t.Fatal("invalid length", len(keys), m.Len())
+		}
+		for i, k := range keys {
+			if r, ok := m.Rank(k); !ok || r != i {
+				t.Fatal("invalid rank", k, r, ok, i)
+			}
+			if key, v, ok := m.At(i); !ok || key != k || v != k {
+				t.Fatal("invalid at", i, key, v, ok, k)
+			}
+		}
+		if _, _, ok := m.At(len(keys)); ok {
+			t.Fatal("invalid")
+		}
+		if _, _, ok := m.At(-1); ok {
+			t.Fatal("invalid")
+		}
+	}
+	check()
+	for i := 0; i < 1000; i++ {
+		k := int(fastrand.Uint32n(500))
+		switch fastrand.Uint32n(6) {
+		case 0:
+			m.Delete(k)
+		case 1:
+			m.LoadAndDelete(k)
+		case 2:
+			m.LoadOrStore(k, k)
+		case 3:
+			if i%50 == 0 {
+				m.DeleteRange(k, k+10, Closed)
+			}
+		case 4:
+			if i%10 == 0 {
+				m.PopMin()
+			}
+		default:
+			m.Store(k, k)
+		}
+		if i%100 == 0 {
+			check()
+		}
+	}
+	check()
+	if r, ok := m.Rank(-1); ok || (r != 0 && r != m.Len()) {
+		t.Fatal("invalid", r, ok)
+	}
+
+	// Concurrent.
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 1000; i++ {
+				k := int(fastrand.Uint32n(500))
+				if fastrand.Uint32n(2) == 0 {
+					m.Store(k, k)
+				} else {
+					m.Delete(k)
+				}
+				m.Rank(k)
+				m.At(k)
+			}
+		}()
+	}
+	wg.Wait()
+	check()
+}
+
+func TestCountRange(t *testing.T) {
+	const n = 100000
+	m := NewInt[int]()
+	mi := NewIntDesc[int](WithIndex())
+	for i := 0; i < n; i++ {
+		m.Store(i, i)
+		mi.Store(i, i)
+	}
+	for _, bounds := range []Bounds{Closed, ExcludeLo, ExcludeHi, Open} {
+		for i := 0; i < 100; i++ {
+			lo, hi := int(fastrand.Uint32n(n)), int(fastrand.Uint32n(n))
+			if lo > hi {
+				lo, hi = hi, lo
+			}
+			want := hi - lo + 1
+			if bounds&ExcludeLo != 0 {
+				want--
+			}
+			if bounds&ExcludeHi != 0 {
+				want--
+			}
+			if want < 0 {
+				want = 0
+			}
+			if got, exact := mi.CountRange(hi, lo, bounds); got != want || !exact {
+				t.Fatal("invalid", lo, hi, bounds, got, want, exact)
+			}
+			got, exact := m.CountRange(lo, hi, bounds)
+			if exact && got != want {
+				t.Fatal("invalid", lo, hi, bounds, got, want)
+			}
+			if !exact && math.Abs(float64(got-want)) > float64(want)/5 {
+				t.Fatal("invalid estimation", lo, hi, bounds, got, want)
+			}
+		}
+	}
+	if got, exact := m.CountRange(10, 20, Closed); got != 11 || !exact {
+		t.Fatal("invalid", got, exact)
+	}
+	if got, exact := m.CountRange(20, 10, Closed); got != 0 || !exact {
+		t.Fatal("invalid", got, exact)
+	}
+	if got, exact := mi.CountRange(10, 20, Closed); got != 0 || !exact {
+		t.Fatal("invalid", got, exact)
+	}
+	if got, exact := m.CountRange(0, n, Closed); exact || math.Abs(float64(got-n)) > n/5 {
+		t.Fatal("invalid", got, exact)
+	}
+}
+
+type prefixskipmap interface {
+	Store(key string, value int)
+	Load(key string) (int, bool)
+	Len() int
+	RangePrefix(prefix string, f func(key string, value int) bool)
+	CountPrefix(prefix string) int
+	DeletePrefix(prefix string) int
+}
+
+// orderedPrefixMap and orderedPrefixMapDesc adapt the prefix functions of OrderedMap and OrderedMapDesc.
+type orderedPrefixMap[K ~string] struct{ *OrderedMap[K, int] }
+
+func (m orderedPrefixMap[K]) RangePrefix(prefix K, f func(key K, value int) bool) {
+	RangePrefix(m.OrderedMap, prefix, f)
+}
+
+func (m orderedPrefixMap[K]) CountPrefix(prefix K) int {
+	return CountPrefix(m.OrderedMap, prefix)
+}
+
+func (m orderedPrefixMap[K]) DeletePrefix(prefix K) int {
+	return DeletePrefix(m.OrderedMap, prefix)
+}
+
+type orderedPrefixMapDesc[K ~string] struct{ *OrderedMapDesc[K, int] }
+
+func (m orderedPrefixMapDesc[K]) RangePrefix(prefix K, f func(key K, value int) bool) {
+	RangePrefixDesc(m.OrderedMapDesc, prefix, f)
+}
+
+func (m orderedPrefixMapDesc[K]) CountPrefix(prefix K) int {
+	return CountPrefixDesc(m.OrderedMapDesc, prefix)
+}
+
+func (m orderedPrefixMapDesc[K]) DeletePrefix(prefix K) int {
+	return DeletePrefixDesc(m.OrderedMapDesc, prefix)
+}
+
+func TestPrefix(t *testing.T) {
+	keys := []string{"", "a", "a/1", "a/2", "a/2/x", "a\xff", "a\xff\xff", "ab", "b", "b/1", "\xff", "\xff\xff", "\xff\xffz"}
+	prefixes := []string{"", "a", "a/", "a/2", "a\xff", "ab", "abc", "b", "c", "\xff", "\xff\xff", "\xff\xff\xff"}
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	for _, desc := range []bool{false, true} {
+		for _, prefix := range prefixes {
+			var want []string
+			for _, k := range sorted {
+				if strings.HasPrefix(k, prefix) {
+					want = append(want, k)
+				}
+			}
+			if desc {
+				for i, j := 0, len(want)-1; i < j; i, j = i+1, j-1 {
+					want[i], want[j] = want[j], want[i]
+				}
+			}
+			var maps []prefixskipmap
+			if desc {
+				maps = []prefixskipmap{NewStringDesc[int](), orderedPrefixMapDesc[string]{NewDesc[string, int]()}, NewStringDesc[int](WithIndex())}
+			} else {
+				maps = []prefixskipmap{NewString[int](), orderedPrefixMap[string]{New[string, int]()}, NewString[int](WithIndex())}
+			}
+			for _, m := range maps {
+				for i, k := range keys {
+					m.Store(k, i)
+				}
+				var got []string
+				m.RangePrefix(prefix, func(key string, value int) bool {
+					got = append(got, key)
+					return true
+				})
+				if !reflect.DeepEqual(got, want) {
+					t.Fatalf("invalid %q %v: %q %q", prefix, desc, got, want)
+				}
+				if n := m.CountPrefix(prefix); n != len(want) {
+					t.Fatal("invalid", prefix, desc, n, len(want))
+				}
+				if len(want) > 1 {
+					var first []string
+					m.RangePrefix(prefix, func(key string, value int) bool {
+						first = append(first, key)
+						return false
+					})
+					if len(first) != 1 || first[0] != want[0] {
+						t.Fatal("invalid", prefix, desc, first)
+					}
+				}
+				if n := m.DeletePrefix(prefix); n != len(want) || m.Len() != len(keys)-len(want) {
+					t.Fatal("invalid", prefix, desc, n, m.Len())
+				}
+				for _, k := range keys {
+					if _, ok := m.Load(k); ok == strings.HasPrefix(k, prefix) {
+						t.Fatal("invalid", prefix, desc, k, ok)
+					}
+				}
+			}
+		}
+	}
+
+	type path string
+	m := New[path, int]()
+	m.Store("x/1", 1)
+	m.Store("x/2", 2)
+	m.Store("y/1", 3)
+	if n := CountPrefix(m, "x/"); n != 2 {
+		t.Fatal("invalid", n)
+	}
+}
+
+func TestPage(t *testing.T) {
+	m := NewInt[int]()
+	for i := 0; i < 100; i += 2 {
+		m.Store(i, i*10)
+	}
+	// Walk all the pages, deleting the cursor key between calls.
+	var got []int
+	entries, next, more := m.FirstPage(7)
+	for {
+		for _, e := range entries {
+			if e.Value != e.Key*10 {
+				t.Fatal("invalid", e)
+			}
+			got = append(got, e.Key)
+		}
+		if !more {
+			break
+		}
+		m.Delete(next)
+		entries, next, more = m.Page(next, 7)
+	}
+	if len(got) != 50 {
+		t.Fatal("invalid", len(got))
+	}
+	for i, k := range got {
+		if k != i*2 {
+			t.Fatal("invalid", i, k)
+		}
+	}
+	if next != 98 {
+		t.Fatal("invalid", next)
+	}
+
+	m = NewInt[int]()
+	for i := 0; i < 10; i++ {
+		m.Store(i, i)
+	}
+	if entries, next, more := m.Page(3, 3); len(entries) != 3 || entries[0].Key != 4 || next != 6 || !more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := m.Page(6, 3); len(entries) != 3 || next != 9 || more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := m.Page(9, 3); len(entries) != 0 || next != 9 || more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := m.Page(-1, 0); len(entries) != 0 || next != -1 || !more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := m.PageReverse(5, 3); len(entries) != 3 || entries[0].Key != 4 || next != 2 || !more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := m.PageReverse(2, 3); len(entries) != 2 || next != 0 || more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := m.LastPage(4); len(entries) != 4 || entries[0].Key != 9 || next != 6 || !more {
+		t.Fatal("invalid", entries, next, more)
+	}
+
+	md := NewStringDesc[int]()
+	for _, k := range []string{"a", "b", "c", "d"} {
+		md.Store(k, 0)
+	}
+	if entries, next, more := md.Page("c", 10); len(entries) != 2 || entries[0].Key != "b" || next != "a" || more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := md.FirstPage(1); len(entries) != 1 || next != "d" || !more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, next, more := md.LastPage(3); len(entries) != 3 || entries[0].Key != "a" || next != "c" || !more {
+		t.Fatal("invalid", entries, next, more)
+	}
+	if entries, _, more := NewInt[int]().FirstPage(10); len(entries) != 0 || more {
+		t.Fatal("invalid", entries, more)
+	}
+}
+
+func TestCompareAndSwapFunc(t *testing.T) {
+	m := NewString[[]int]()
+	m.Store("a", []int{1, 2})
+	equal := func(a, b []int) bool { return reflect.DeepEqual(a, b) }
+	if m.CompareAndSwapFunc("a", []int{1}, []int{3}, equal) || m.CompareAndSwapFunc("b", nil, []int{3}, equal) {
+		t.Fatal("invalid")
+	}
+	if !m.CompareAndSwapFunc("a", []int{1, 2}, []int{3}, equal) {
+		t.Fatal("invalid")
+	}
+	if m.CompareAndDeleteFunc("a", []int{1, 2}, equal) || !m.CompareAndDeleteFunc("a", []int{3}, equal) || m.Len() != 0 {
+		t.Fatal("invalid")
+	}
+
+	// A Store between the comparison and the deletion, the value is compared again.
+	m.Store("a", []int{4})
+	calls := 0
+	racing := func(a, b []int) bool {
+		calls++
+		if calls == 1 {
+			done := make(chan struct{})
+			go func() {
+				m.Store("a", []int{5})
+				close(done)
+			}()
+			<-done
+		}
+		return equal(a, b)
+	}
+	if m.CompareAndDeleteFunc("a", []int{4}, racing) || calls != 2 {
+		t.Fatal("invalid", calls)
+	}
+	if v, ok := m.Load("a"); !ok || !equal(v, []int{5}) || m.Len() != 1 || !m.Delete("a") {
+		t.Fatal("invalid", v, ok)
+	}
+	m.Store("a", []int{1})
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic")
+		}
+	}()
+	m.CompareAndSwap("a", []int{1}, nil)
+}
+
+func TestCounter(t *testing.T) {
+	m := NewStringCounter()
+	if v, ok := m.Load("a"); ok || v != 0 {
+		t.Fatal("invalid", v, ok)
+	}
+	if v := m.Add("a", 2); v != 2 || m.Len() != 1 {
+		t.Fatal("invalid", v)
+	}
+	if v := m.Add("a", -3); v != -1 {
+		t.Fatal("invalid", v)
+	}
+	if previous, loaded := m.Swap("a", 10); previous != -1 || !loaded {
+		t.Fatal("invalid", previous, loaded)
+	}
+	if previous, loaded := m.Swap("b", 5); previous != 0 || loaded || m.Len() != 2 {
+		t.Fatal("invalid", previous, loaded)
+	}
+	if previous := m.Reset("a"); previous != 10 {
+		t.Fatal("invalid", previous)
+	}
+	if previous := m.Reset("c"); previous != 0 || m.Len() != 2 {
+		t.Fatal("invalid", previous)
+	}
+	if v, ok := m.Load("a"); !ok || v != 0 {
+		t.Fatal("invalid", v, ok)
+	}
+	if !m.Delete("a") || m.Delete("a") || m.Len() != 1 {
+		t.Fatal("invalid")
+	}
+	if allocs := testing.AllocsPerRun(100, func() { m.Add("b", 1) }); allocs != 0 {
+		t.Fatal("Add allocates", allocs)
+	}
+
+	md := NewIntCounterDesc()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func() {
+			for i := 0; i < 1000; i++ {
+				md.Add(i%10, 1)
+			}
+			wg.Done()
+		}()
+	}
+	wg.Wait()
+	prev := 10
+	md.Range(func(key int, value int64) bool {
+		if key >= prev || value != 800 {
+			t.Fatal("invalid", key, value)
+		}
+		prev = key
+		return true
+	})
+	if md.Len() != 10 || prev != 0 {
+		t.Fatal("invalid", md.Len(), prev)
+	}
+
+	// The Adds race with the Deletes of the same keys, an Add returning 1 has inserted the key.
+	mc := NewIntCounter()
+	var inserted, deleted int64
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			for i := 0; i < 5000; i++ {
+				if g < 2 {
+					if mc.Delete(i % 4) {
+						atomic.AddInt64(&deleted, 1)
+					}
+				} else if mc.Add(i%4, 1) == 1 {
+					atomic.AddInt64(&inserted, 1)
+				}
+			}
+			wg.Done()
+		}(g)
+	}
+	wg.Wait()
+	count := 0
+	mc.Range(func(key int, value int64) bool {
+		count++
+		return true
+	})
+	if n := int(inserted - deleted); mc.Len() != n || count != n {
+		t.Fatal("invalid", mc.Len(), count, n)
+	}
+
+	mo := NewCounter[uint8]()
+	for i := 0; i < 300; i++ {
+		mo.Add(uint8(i), int64(i))
+	}
+	if v, _ := mo.Load(1); mo.Len() != 256 || v != 1+257 {
+		t.Fatal("invalid", mo.Len(), v)
+	}
+}
+
+func TestLoadOrCompute(t *testing.T) {
+	m := NewString[int](WithIndex())
+	errFailed := errors.New("failed")
+	if v, loaded, err := m.LoadOrCompute("a", func() (int, error) { return 1, errFailed }); v != 0 || loaded || err != errFailed || m.Len() != 0 {
+		t.Fatal("invalid", v, loaded, err)
+	}
+	// f can use the skipmap, even if it is indexed.
+	if v, loaded, err := m.LoadOrCompute("a", func() (int, error) {
+		m.Store("b", 2)
+		v, _ := m.LoadOrStoreLazy("c", func() int { return 3 })
+		return v + 1, nil
+	}); v != 4 || loaded || err != nil || m.Len() != 3 {
+		t.Fatal("invalid", v, loaded, err)
+	}
+	if v, loaded, err := m.LoadOrCompute("a", func() (int, error) { panic("unreachable") }); v != 4 || !loaded || err != nil {
+		t.Fatal("invalid", v, loaded, err)
+	}
+
+	// The concurrent callers for the same key wait for a single call.
+	var (
+		wg      sync.WaitGroup
+		calls   int64
+		stored  int64
+		release = make(chan struct{})
+	)
+	for _, result := range []error{errFailed, nil} {
+		calls, stored = 0, 0
+		for i := 0; i < 100; i++ {
+			wg.Add(1)
+			go func(result error) {
+				defer wg.Done()
+				v, loaded, err := m.LoadOrCompute("d", func() (int, error) {
+					atomic.AddInt64(&calls, 1)
+					<-release
+					return 5, result
+				})
+				if err != result || (err == nil && v != 5) || (err != nil && loaded) {
+					panic("invalid LoadOrCompute")
+				}
+				if !loaded && err == nil {
+					atomic.AddInt64(&stored, 1)
+				}
+			}(result)
+		}
+		time.Sleep(10 * time.Millisecond)
+		release <- struct{}{}
+		wg.Wait()
+		if calls != 1 || (result == nil) != (stored == 1) {
+			t.Fatal("invalid", result, calls, stored)
+		}
+	}
+	// Unrelated keys are not blocked by a slow call.
+	go m.LoadOrStoreLazy("e", func() int {
+		<-release
+		return 0
+	})
+	for i := 0; i < 100; i++ {
+		m.LoadOrStoreLazy(strconv.Itoa(i), func() int { return i })
+	}
+	release <- struct{}{}
+}
+
+func TestCallbackPanic(t *testing.T) {
+	mustPanic := func(f func()) {
+		defer func() {
+			if recover() == nil {
+				t.Fatal("should panic")
+			}
+		}()
+		f()
+	}
+	for _, m := range []*IntMap[int]{NewInt[int](), NewInt[int](WithIndex())} {
+		for i := 0; i < 10; i++ {
+			m.Store(i, i)
+		}
+		mustPanic(func() { m.LoadOrStoreLazy(10, func() int { panic("f") }) })
+		mustPanic(func() { m.Compute(5, func(old int, loaded bool) (int, Op) { panic("f") }) })
+		mustPanic(func() { m.CompareAndDeleteFunc(6, 6, func(a, b int) bool { panic("equal") }) })
+		mustPanic(func() { m.CompareAndSwapFunc(7, 7, 70, func(a, b int) bool { panic("equal") }) })
+
+		// The locks are released and the skipmap is unchanged.
+		if _, ok := m.Load(10); ok || m.Len() != 10 {
+			t.Fatal("invalid", m.Len())
+		}
+		if v, loaded := m.LoadOrStoreLazy(10, func() int { return 10 }); v != 10 || loaded {
+			t.Fatal("invalid", v, loaded)
+		}
+		if v, ok := m.Compute(5, func(old int, loaded bool) (int, Op) { return old * 10, OpStore }); v != 50 || !ok {
+			t.Fatal("invalid", v, ok)
+		}
+		if !m.CompareAndDelete(6, 6) || !m.CompareAndSwap(7, 7, 70) || !m.Delete(4) {
+			t.Fatal("invalid")
+		}
+		m.Store(4, 40)
+		var keys []int
+		m.Range(func(key, value int) bool {
+			keys = append(keys, key)
+			return true
+		})
+		if !reflect.DeepEqual(keys, []int{0, 1, 2, 3, 4, 5, 7, 8, 9, 10}) || m.Len() != 10 {
+			t.Fatal("invalid", keys)
+		}
+	}
+
+	// The comparator panics while StoreSorted holds the locks.
+	mf := NewFunc[int, int](func(a, b int) bool {
+		if a == 5 && b == 10 {
+			panic("less")
+		}
+		return a < b
+	})
+	mf.Store(10, 10)
+	mustPanic(func() { mf.StoreSorted([]int{1, 5}, []int{1, 5}) })
+	mf.Store(3, 3)
+	if v, ok := mf.Load(1); !ok || v != 1 || mf.Len() != 3 {
+		t.Fatal("invalid", v, ok, mf.Len())
+	}
+
+	// The comparator panics while the commit of Update holds the locks.
+	for _, opts := range [][]Option{{WithTxn()}, {WithTxn(), WithIndex()}, {WithTxn(), WithSnapshot()}} {
+		broken := true
+		mt := NewFunc[int, int](func(a, b int) bool {
+			// The key 10 is not in the transaction, so only the commit compares it with 1.
+			if broken && ((a == 1 && b == 10) || (a == 10 && b == 1)) {
+				panic("less")
+			}
+			return a < b
+		}, opts...)
+		for _, k := range []int{10, 20, 30} {
+			mt.Store(k, k)
+		}
+		update := func() {
+			mt.Update(func(tx *FuncTxn[int, int]) error {
+				tx.Put(30, 300)
+				tx.Put(15, 150)
+				tx.Delete(20)
+				tx.Put(1, 1)
+				return nil
+			})
+		}
+		mustPanic(update)
+		if v, ok := mt.Load(20); !ok || v != 20 || mt.Len() != 3 {
+			t.Fatal("invalid", v, ok, mt.Len())
+		}
+		if v, ok := mt.Load(30); !ok || v != 30 {
+			t.Fatal("invalid", v, ok)
+		}
+		if _, ok := mt.Load(15); ok {
+			t.Fatal("invalid")
+		}
+		broken = false
+		update()
+		var keys []int
+		mt.Range(func(key, value int) bool {
+			keys = append(keys, key)
+			return true
+		})
+		if !reflect.DeepEqual(keys, []int{1, 10, 15, 30}) || mt.Len() != 4 {
+			t.Fatal("invalid", keys, mt.Len())
+		}
+	}
+
+	// The comparator panics while removing a marked node. The stored keys have id 0 and the keys
+	// passed by the test have id 1, so only the search for a node's own key compares equal keys.
+	type idKey struct{ k, id int }
+	for _, opts := range [][]Option{{WithTxn()}, {WithTxn(), WithIndex()}, {WithTxn(), WithSnapshot()}} {
+		broken := false
+		md := NewFunc[idKey, int](func(a, b idKey) bool {
+			if broken && a == b {
+				panic("less")
+			}
+			return a.k < b.k
+		}, opts...)
+		for i := 0; i < 10; i++ {
+			md.Store(idKey{i, 0}, i)
+		}
+		broken = true
+		mustPanic(func() { md.DeleteRange(idKey{5, 1}, idKey{5, 1}, Closed) })
+		mustPanic(func() { md.PopMin() })
+		mustPanic(func() {
+			md.Update(func(tx *FuncTxn[idKey, int]) error {
+				tx.Delete(idKey{7, 1})
+				tx.Delete(idKey{8, 1})
+				return nil
+			})
+		})
+		broken = false
+		if !md.Delete(idKey{6, 1}) || !md.Delete(idKey{4, 1}) {
+			t.Fatal("invalid")
+		}
+		md.Store(idKey{5, 0}, 50)
+		var keys []int
+		md.Range(func(key idKey, value int) bool {
+			keys = append(keys, key.k)
+			return true
+		})
+		if !reflect.DeepEqual(keys, []int{1, 2, 3, 5, 9}) || md.Len() != 5 {
+			t.Fatal("invalid", keys, md.Len())
+		}
+		if r, ok := md.Rank(idKey{9, 1}); !ok || r != 4 {
+			t.Fatal("invalid", r, ok)
+		}
+	}
+	// The deleted node is kept for a snapshot, which compares it with the earlier one of the same key.
+	ms := NewFunc[idKey, int](func(a, b idKey) bool {
+		if a == b && a.k == 3 {
+			panic("less")
+		}
+		return a.k < b.k
+	}, WithSnapshot())
+	ms.Store(idKey{3, 0}, 3)
+	sn := ms.Snapshot()
+	ms.Delete(idKey{3, 1})
+	ms.Store(idKey{3, 0}, 30)
+	sn2 := ms.Snapshot()
+	mustPanic(func() { ms.Delete(idKey{3, 1}) })
+	sn.Close()
+	sn2.Close()
+	ms.Store(idKey{3, 0}, 300)
+	if v, ok := ms.Load(idKey{3, 1}); !ok || v != 300 || ms.Len() != 1 {
+		t.Fatal("invalid", v, ok, ms.Len())
+	}
+
+	// The waiters call f again if it panics.
+	m := NewInt[int]()
+	var (
+		wg      sync.WaitGroup
+		calls   int64
+		release = make(chan struct{})
+	)
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() {
+				if r := recover(); r != nil && r != "f" {
+					panic(r)
+				}
+			}()
+			v, _ := m.LoadOrStoreLazy(1, func() int {
+				if atomic.AddInt64(&calls, 1) == 1 {
+					<-release
+					panic("f")
+				}
+				return 1
+			})
+			if v != 1 {
+				panic("invalid LoadOrStoreLazy")
+			}
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	if v, ok := m.Load(1); !ok || v != 1 || calls != 2 {
+		t.Fatal("invalid", v, ok, calls)
+	}
+}
+
+func TestFromSorted(t *testing.T) {
+	keys := make([]int, 1000)
+	values := make([]string, len(keys))
+	for i := range keys {
+		keys[i] = i * 2
+		values[i] = strconv.Itoa(i * 2)
+	}
+	for _, opts := range [][]Option{nil, {WithIndex()}} {
+		m, err := NewIntFromSorted(keys, values, opts...)
+		if err != nil || m.Len() != len(keys) {
+			t.Fatal("invalid", err)
+		}
+		i := 0
+		m.Range(func(key int, value string) bool {
+			if key != keys[i] || value != values[i] {
+				t.Fatal("invalid", key, value)
+			}
+			i++
+			return true
+		})
+		for i, key := range keys {
+			if v, ok := m.Load(key); !ok || v != values[i] {
+				t.Fatal("invalid", key, v)
+			}
+			if r, ok := m.Rank(key); !ok || r != i {
+				t.Fatal("invalid", key, r)
+			}
+			if k, _, ok := m.At(i); !ok || k != key {
+				t.Fatal("invalid", i, k)
+			}
+		}
+		// The skipmap works as usual.
+		m.Store(1, "1")
+		m.Delete(0)
+		if r, ok := m.Rank(1); !ok || r != 0 || m.Len() != len(keys) {
+			t.Fatal("invalid", r)
+		}
+		if k, _, ok := m.At(len(keys) - 1); !ok || k != keys[len(keys)-1] {
+			t.Fatal("invalid", k)
+		}
+	}
+
+	if m, err := NewIntFromSorted[int](nil, nil); err != nil || m.Len() != 0 {
+		t.Fatal("invalid", err)
+	}
+	if m, err := NewIntFromSorted([]int{1, 2, 2}, []int{1, 2, 3}); err != ErrUnsorted || m != nil {
+		t.Fatal("invalid", err)
+	}
+	if _, err := NewIntDescFromSorted([]int{1, 2}, []int{1, 2}); err != ErrUnsorted {
+		t.Fatal("invalid", err)
+	}
+	if m, err := NewIntDescFromSorted([]int{2, 1}, []int{2, 1}); err != nil || m.Len() != 2 {
+		t.Fatal("invalid", err)
+	}
+	if m, err := NewFloat64FromSorted([]float64{math.NaN(), -1, 1}, []int{0, 1, 2}); err != nil || m.Len() != 3 {
+		t.Fatal("invalid", err)
+	}
+	less := func(a, b string) bool { return len(a) < len(b) }
+	if _, err := NewFuncFromSorted(less, []string{"a", "bb", "cc"}, []int{1, 2, 3}); err != ErrUnsorted {
+		t.Fatal("invalid", err)
+	}
+	if m, err := NewFuncFromSorted(less, []string{"a", "bb", "ccc"}, []int{1, 2, 3}); err != nil || m.Len() != 3 {
+		t.Fatal("invalid", err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic")
+		}
+	}()
+	NewStringFromSorted([]string{"a"}, []int{})
+}
+
+func TestStoreBatch(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithIndex()}} {
+		m := NewInt[int](opts...)
+		expected := make(map[int]int)
+		for round := 0; round < 10; round++ {
+			keys := make([]int, 500)
+			values := make([]int, len(keys))
+			for i := range keys {
+				keys[i] = int(fastrand.Uint32n(2000))
+				values[i] = round*10000 + i
+				expected[keys[i]] = values[i]
+			}
+			m.StoreBatch(keys, values)
+			del := make([]int, 100)
+			for i := range del {
+				del[i] = int(fastrand.Uint32n(2000))
+			}
+			n := 0
+			for _, k := range del {
+				if _, ok := expected[k]; ok {
+					delete(expected, k)
+					n++
+				}
+			}
+			if deleted := m.DeleteBatch(del); deleted != n {
+				t.Fatal("invalid", deleted, n)
+			}
+			if m.Len() != len(expected) {
+				t.Fatal("invalid", m.Len(), len(expected))
+			}
+			i := 0
+			m.Range(func(key, value int) bool {
+				if v, ok := expected[key]; !ok || v != value {
+					t.Fatal("invalid", key, value, v)
+				}
+				if r, ok := m.Rank(key); !ok || r != i {
+					t.Fatal("invalid", key, r, i)
+				}
+				i++
+				return true
+			})
+		}
+	}
+
+	m := NewStringDesc[int]()
+	if err := m.StoreSorted([]string{"a", "b"}, []int{1, 2}); err != ErrUnsorted || m.Len() != 0 {
+		t.Fatal("invalid", err)
+	}
+	if err := m.StoreSorted([]string{"c", "b", "a"}, []int{3, 2, 1}); err != nil || m.Len() != 3 {
+		t.Fatal("invalid", err)
+	}
+	if err := m.StoreSorted([]string{"d", "b"}, []int{4, 20}); err != nil || m.Len() != 4 {
+		t.Fatal("invalid", err)
+	}
+	if v, _ := m.Load("b"); v != 20 {
+		t.Fatal("invalid", v)
+	}
+	if k, _, _ := m.Min(); k != "d" {
+		t.Fatal("invalid", k)
+	}
+	f := NewFunc[int, int](func(a, b int) bool { return a > b })
+	f.StoreBatch([]int{1, 3, 2, 3}, []int{1, 3, 2, 30})
+	if v, _ := f.Load(3); v != 30 || f.Len() != 3 || f.DeleteBatch([]int{2, 3, 3}) != 2 || f.Len() != 1 {
+		t.Fatal("invalid", v, f.Len())
+	}
+
+	// Concurrent batches with the other writers.
+	c := NewInt[int]()
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for round := 0; round < 20; round++ {
+				start := int(fastrand.Uint32n(1000))
+				keys := make([]int, 100)
+				for i := range keys {
+					keys[i] = start + i*(g%3+1)
+				}
+				switch g % 4 {
+				case 0:
+					c.StoreSorted(keys, keys)
+				case 1:
+					c.StoreBatch(keys, keys)
+				case 2:
+					c.DeleteBatch(keys)
+				default:
+					for _, k := range keys {
+						c.Store(k, k)
+						c.Delete(k + 1)
+					}
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+	n := 0
+	prev := -1
+	c.Range(func(key, value int) bool {
+		if key <= prev || key != value {
+			t.Fatal("invalid", key, value)
+		}
+		prev = key
+		n++
+		return true
+	})
+	if n != c.Len() {
+		t.Fatal("invalid", n, c.Len())
+	}
+}
+
+func TestLoadMany(t *testing.T) {
+	m := NewInt[int](WithIndex())
+	for i := 0; i < 1000; i += 2 {
+		m.Store(i, i*10)
+	}
+	check := func(keys []int) {
+		out := make([]int, len(keys))
+		found := make([]bool, len(keys))
+		for i := range out {
+			out[i] = -1
+		}
+		m.LoadMany(keys, out, found)
+		for i, key := range keys {
+			if v, ok := m.Load(key); v != out[i] || ok != found[i] {
+				t.Fatal("invalid", key, out[i], found[i])
+			}
+		}
+	}
+	check(nil)
+	check([]int{0, 1, 2, 2, 3, 998, 999, 1000})
+	keys := make([]int, 300)
+	for i := range keys {
+		keys[i] = int(fastrand.Uint32n(1100)) - 50
+	}
+	check(keys)
+
+	f := NewFunc[string, int](func(a, b string) bool { return a > b })
+	f.Store("a", 1)
+	f.Store("c", 3)
+	out, found := make([]int, 4), make([]bool, 4)
+	f.LoadMany([]string{"a", "b", "c", "a"}, out, found)
+	if !reflect.DeepEqual(out, []int{1, 0, 3, 1}) || !reflect.DeepEqual(found, []bool{true, false, true, true}) {
+		t.Fatal("invalid", out, found)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic")
+		}
+	}()
+	f.LoadMany([]string{"a"}, nil, nil)
+}
+
+func TestClone(t *testing.T) {
+	for _, opts := range [][]Option{nil, {WithIndex()}} {
+		m := NewInt[int](opts...)
+		for i := 0; i < 1000; i++ {
+			m.Store(i, i)
+		}
+		m.Delete(500)
+		c := m.Clone()
+		if c.Len() != m.Len() || (c.index == nil) != (m.index == nil) {
+			t.Fatal("invalid", c.Len())
+		}
+		// The nodes keep their levels.
+		for x, y := m.load().header.loadNext(0), c.load().header.loadNext(0); x != nil || y != nil; x, y = x.loadNext(0), y.loadNext(0) {
+			if x == nil || y == nil || x.key != y.key || x.level != y.level || x.loadVal() != y.loadVal() {
+				t.Fatal("invalid clone")
+			}
+		}
+		// The copy is independent.
+		c.Store(500, 500)
+		c.Delete(0)
+		m.Store(1, 10)
+		if _, ok := m.Load(500); ok {
+			t.Fatal("invalid")
+		}
+		if _, ok := m.Load(0); !ok {
+			t.Fatal("invalid")
+		}
+		if v, _ := c.Load(1); v != 1 {
+			t.Fatal("invalid", v)
+		}
+		for i := 1; i < 1000; i++ {
+			if r, ok := c.Rank(i); !ok || r != i-1 {
+				t.Fatal("invalid", i, r)
+			}
+		}
+	}
+
+	f := NewFunc[int, int](func(a, b int) bool { return a > b })
+	f.Store(1, 1)
+	f.Store(2, 2)
+	c := f.Clone()
+	c.Store(3, 3)
+	if k, _, _ := c.Min(); k != 3 || c.Len() != 3 || f.Len() != 2 {
+		t.Fatal("invalid", k)
+	}
+
+	// The keys not changed during Clone are always copied.
+	m := NewInt[int]()
+	for i := 0; i < 1000; i++ {
+		m.Store(i*2, i)
+	}
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			m.Store(i*2+1, i)
+			m.Delete(i*2 + 1)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		c := m.Clone()
+		for j := 0; j < 1000; j++ {
+			if v, ok := c.Load(j * 2); !ok || v != j {
+				t.Fatal("invalid", j, v)
+			}
+		}
+	}
+	wg.Wait()
+}
+
+func TestSnapshot(t *testing.T) {
+	for _, opts := range [][]Option{{WithSnapshot()}, {WithSnapshot(), WithIndex()}} {
+		m := NewInt[int](opts...)
+		for i := 0; i < 100; i++ {
+			m.Store(i, i)
+		}
+		sn := m.Snapshot()
+		m.Store(0, 100)
+		m.Swap(1, 101)
+		m.CompareAndSwap(2, 2, 102)
+		m.Compute(3, func(old int, loaded bool) (int, Op) { return old + 100, OpStore })
+		m.Delete(4)
+		m.CompareAndDelete(5, 5)
+		m.DeleteRange(10, 19, Closed)
+		m.Store(200, 200)
+		m.Store(4, 104) // deleted and inserted again
+		if sn.Len() != 100 || m.Len() != 90 {
+			t.Fatal("invalid", sn.Len(), m.Len())
+		}
+		for i := 0; i < 100; i++ {
+			if v, ok := sn.Load(i); !ok || v != i {
+				t.Fatal("invalid", i, v, ok)
+			}
+		}
+		if _, ok := sn.Load(200); ok {
+			t.Fatal("invalid")
+		}
+		var keys []int
+		sn.Range(func(key, value int) bool {
+			if key != value {
+				t.Fatal("invalid", key, value)
+			}
+			keys = append(keys, key)
+			return true
+		})
+		if len(keys) != 100 || !sort.IntsAreSorted(keys) {
+			t.Fatal("invalid", keys)
+		}
+
+		// The snapshots see the writes before them.
+		sn2 := m.Snapshot()
+		m.Store(0, 1000)
+		m.Clear()
+		if v, _ := sn.Load(0); v != 0 {
+			t.Fatal("invalid", v)
+		}
+		if v, _ := sn2.Load(0); v != 100 {
+			t.Fatal("invalid", v)
+		}
+		if v, ok := sn2.Load(4); !ok || v != 104 {
+			t.Fatal("invalid", v)
+		}
+		if _, ok := sn2.Load(10); ok || sn2.Len() != 90 {
+			t.Fatal("invalid")
+		}
+		n := 0
+		sn2.Range(func(key, value int) bool {
+			n++
+			return n < 10
+		})
+		if n != 10 {
+			t.Fatal("invalid", n)
+		}
+
+		// The deleted nodes are dropped when no snapshot needs them.
+		sn.Close()
+		sn.Close()
+		sn2.Close()
+		if len(m.snap.live) != 0 || m.load().dead != nil {
+			t.Fatal("invalid")
+		}
+		m.Store(1, 1)
+		m.Store(1, 2)
+		if p := (*version[int])(m.load().header.loadNext(0).value); p.prev != nil {
+			t.Fatal("invalid")
+		}
+	}
+
+	// The snapshots are consistent under concurrent writers.
+	m := NewInt[int](WithSnapshot())
+	const n = 1000
+	for i := 0; i < n; i++ {
+		m.Store(i, 0)
+	}
+	var (
+		wg      sync.WaitGroup
+		stopped int32
+	)
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for round := 1; atomic.LoadInt32(&stopped) == 0; round++ {
+				// Each round moves the keys to a new value, deleting and inserting some of them.
+				for i := 0; i < n; i++ {
+					if i%10 == 0 {
+						m.Delete(i)
+						m.Store(i, round)
+					} else {
+						m.Store(i, round)
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		sn := m.Snapshot()
+		want := make(map[int]int)
+		sn.Range(func(key, value int) bool {
+			want[key] = value
+			return true
+		})
+		if len(want) != sn.Len() {
+			t.Fatal("invalid", len(want), sn.Len())
+		}
+		runtime.Gosched()
+		for key, value := range want {
+			if v, ok := sn.Load(key); !ok || v != value {
+				t.Fatal("invalid", key, v, value)
+			}
+		}
+		again := 0
+		sn.Range(func(key, value int) bool {
+			if want[key] != value {
+				t.Fatal("invalid", key, value)
+			}
+			again++
+			return true
+		})
+		if again != len(want) {
+			t.Fatal("invalid", again, len(want))
+		}
+		sn.Close()
+	}
+	atomic.StoreInt32(&stopped, 1)
+	wg.Wait()
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic")
+		}
+	}()
+	NewInt[int]().Snapshot()
+}
+
+func TestUpdate(t *testing.T) {
+	for _, opts := range [][]Option{{WithTxn()}, {WithTxn(), WithIndex()}, {WithTxn(), WithSnapshot()}} {
+		m := NewInt[int](opts...)
+		m.Store(1, 10)
+		m.Store(2, 20)
+		m.Store(3, 30)
+		err := m.Update(func(tx *IntTxn[int]) error {
+			v, ok := tx.Get(1)
+			if !ok || v != 10 {
+				t.Fatal("invalid", v)
+			}
+			tx.Delete(1)
+			tx.Put(4, v)
+			tx.Put(2, 21)
+			tx.Delete(5) // absent
+			if _, ok := tx.Get(1); ok {
+				t.Fatal("invalid")
+			}
+			if v, ok := tx.Get(4); !ok || v != 10 {
+				t.Fatal("invalid", v)
+			}
+			return nil
+		})
+		if err != nil || m.Len() != 3 {
+			t.Fatal("invalid", err, m.Len())
+		}
+		var keys, values []int
+		m.Range(func(key, value int) bool {
+			keys = append(keys, key)
+			values = append(values, value)
+			return true
+		})
+		if !reflect.DeepEqual(keys, []int{2, 3, 4}) || !reflect.DeepEqual(values, []int{21, 30, 10}) {
+			t.Fatal("invalid", keys, values)
+		}
+		if m.index != nil {
+			if r, ok := m.Rank(4); !ok || r != 2 {
+				t.Fatal("invalid", r)
+			}
+		}
+
+		// The writes are discarded if f fails.
+		errTest := errors.New("test")
+		err = m.Update(func(tx *IntTxn[int]) error {
+			tx.Put(2, 0)
+			tx.Put(100, 0)
+			tx.Delete(3)
+			return errTest
+		})
+		if err != errTest || m.Len() != 3 {
+			t.Fatal("invalid", err)
+		}
+		if v, _ := m.Load(2); v != 21 {
+			t.Fatal("invalid", v)
+		}
+
+		// f is called again if the keys read are changed.
+		calls := 0
+		m.Update(func(tx *IntTxn[int]) error {
+			calls++
+			v, _ := tx.Get(2)
+			tx.Get(6) // absent
+			switch calls {
+			case 1:
+				m.Store(2, 22)
+			case 2:
+				m.Store(6, 60)
+			}
+			tx.Put(3, v)
+			return nil
+		})
+		if v, _ := m.Load(3); calls != 3 || v != 22 {
+			t.Fatal("invalid", calls, v)
+		}
+	}
+
+	// The readers see the writes of a transaction all or nothing.
+	m := NewInt[int](WithTxn())
+	const n = 100
+	for i := 0; i < n; i++ {
+		m.Store(i, 0)
+	}
+	var (
+		wg      sync.WaitGroup
+		stopped int32
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for round := 1; atomic.LoadInt32(&stopped) == 0; round++ {
+			m.Update(func(tx *IntTxn[int]) error {
+				for i := 0; i < n; i++ {
+					tx.Put(i, round)
+				}
+				return nil
+			})
+		}
+	}()
+	for i := 0; i < 1000; i++ {
+		v0, _ := m.Load(0)
+		v1, _ := m.Load(n - 1)
+		if v1 < v0 {
+			t.Fatal("invalid", v0, v1)
+		}
+		last := 0
+		m.Range(func(key, value int) bool {
+			if value < last {
+				t.Fatal("invalid", key, value, last)
+			}
+			last = value
+			return true
+		})
+	}
+	atomic.StoreInt32(&stopped, 1)
+	wg.Wait()
+
+	// The transactions are serializable: moving a token between the keys.
+	for _, opts := range [][]Option{{WithTxn()}, {WithTxn(), WithIndex()}, {WithTxn(), WithSnapshot()}} {
+		m := NewInt[int](opts...)
+		m.Store(0, 1)
+		for g := 0; g < 8; g++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := 0; i < 500; i++ {
+					from, to := int(fastrand.Uint32n(10)), int(fastrand.Uint32n(10))
+					m.Update(func(tx *IntTxn[int]) error {
+						v, ok := tx.Get(from)
+						if !ok || from == to {
+							return nil
+						}
+						tx.Delete(from)
+						tx.Put(to, v)
+						return nil
+					})
+					if i%10 == 0 {
+						m.Store(int(fastrand.Uint32n(10))+10, i) // lock-free writers of other keys
+					}
+				}
+			}()
+		}
+		for i := 0; i < 100; i++ {
+			var count int
+			m.Update(func(tx *IntTxn[int]) error {
+				count = 0
+				for k := 0; k < 10; k++ {
+					if _, ok := tx.Get(k); ok {
+						count++
+					}
+				}
+				return nil
+			})
+			if count != 1 {
+				t.Fatal("invalid", count)
+			}
+		}
+		wg.Wait()
+		count := 0
+		m.Range(func(key, value int) bool {
+			if key < 10 {
+				count++
+			}
+			return true
+		})
+		if count != 1 {
+			t.Fatal("invalid", count)
+		}
+	}
+
+	f := NewFunc[string, int](func(a, b string) bool { return a > b }, WithTxn())
+	f.Update(func(tx *FuncTxn[string, int]) error {
+		tx.Put("a", 1)
+		tx.Put("c", 3)
+		tx.Put("b", 2)
+		return nil
+	})
+	if k, _, _ := f.Min(); k != "c" || f.Len() != 3 {
+		t.Fatal("invalid", k)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("should panic")
+		}
+	}()
+	NewInt[int]().Update(func(tx *IntTxn[int]) error { return nil })
+}
@@ -0,0 +1,216 @@
+package skipmap
+
+// NewFuncFromSorted returns a skipmap in ascending order with the given keys and values,
+// the keys must be strictly in ascending order according to less, see NewFromSorted.
+func NewFuncFromSorted[keyT any, valueT any](less func(a, b keyT) bool, keys []keyT, values []valueT, opts ...Option) (*FuncMap[keyT, valueT], error) {
+	s := NewFunc[keyT, valueT](less, opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewFromSorted returns a skipmap in ascending order with the given keys and values,
+// values[i] is the value of keys[i]. The keys must be strictly ordered by the skipmap's order,
+// i.e. strictly ascending, or strictly descending for the Desc variants such as NewDescFromSorted,
+// or ErrUnsorted is returned. It panics if keys and values have different lengths.
+//
+// The skipmap is built in one pass without locks, which costs O(n) rather than
+// O(n log n) of storing the keys one by one.
+func NewFromSorted[keyT ordered, valueT any](keys []keyT, values []valueT, opts ...Option) (*OrderedMap[keyT, valueT], error) {
+	s := New[keyT, valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewDescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewDescFromSorted[keyT ordered, valueT any](keys []keyT, values []valueT, opts ...Option) (*OrderedMapDesc[keyT, valueT], error) {
+	s := NewDesc[keyT, valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewStringFromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewStringFromSorted[valueT any](keys []string, values []valueT, opts ...Option) (*StringMap[valueT], error) {
+	s := NewString[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewStringDescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewStringDescFromSorted[valueT any](keys []string, values []valueT, opts ...Option) (*StringMapDesc[valueT], error) {
+	s := NewStringDesc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewFloat32FromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewFloat32FromSorted[valueT any](keys []float32, values []valueT, opts ...Option) (*FuncMap[float32, valueT], error) {
+	s := NewFloat32[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewFloat32DescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewFloat32DescFromSorted[valueT any](keys []float32, values []valueT, opts ...Option) (*FuncMap[float32, valueT], error) {
+	s := NewFloat32Desc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewFloat64FromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewFloat64FromSorted[valueT any](keys []float64, values []valueT, opts ...Option) (*FuncMap[float64, valueT], error) {
+	s := NewFloat64[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewFloat64DescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewFloat64DescFromSorted[valueT any](keys []float64, values []valueT, opts ...Option) (*FuncMap[float64, valueT], error) {
+	s := NewFloat64Desc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewIntFromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewIntFromSorted[valueT any](keys []int, values []valueT, opts ...Option) (*IntMap[valueT], error) {
+	s := NewInt[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewIntDescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewIntDescFromSorted[valueT any](keys []int, values []valueT, opts ...Option) (*IntMapDesc[valueT], error) {
+	s := NewIntDesc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewInt64FromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewInt64FromSorted[valueT any](keys []int64, values []valueT, opts ...Option) (*Int64Map[valueT], error) {
+	s := NewInt64[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewInt64DescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewInt64DescFromSorted[valueT any](keys []int64, values []valueT, opts ...Option) (*Int64MapDesc[valueT], error) {
+	s := NewInt64Desc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewInt32FromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewInt32FromSorted[valueT any](keys []int32, values []valueT, opts ...Option) (*Int32Map[valueT], error) {
+	s := NewInt32[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewInt32DescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewInt32DescFromSorted[valueT any](keys []int32, values []valueT, opts ...Option) (*Int32MapDesc[valueT], error) {
+	s := NewInt32Desc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewUint64FromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewUint64FromSorted[valueT any](keys []uint64, values []valueT, opts ...Option) (*Uint64Map[valueT], error) {
+	s := NewUint64[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewUint64DescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewUint64DescFromSorted[valueT any](keys []uint64, values []valueT, opts ...Option) (*Uint64MapDesc[valueT], error) {
+	s := NewUint64Desc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewUint32FromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewUint32FromSorted[valueT any](keys []uint32, values []valueT, opts ...Option) (*Uint32Map[valueT], error) {
+	s := NewUint32[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewUint32DescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewUint32DescFromSorted[valueT any](keys []uint32, values []valueT, opts ...Option) (*Uint32MapDesc[valueT], error) {
+	s := NewUint32Desc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewUintFromSorted returns a skipmap in ascending order with the given keys and values,
+// see NewFromSorted.
+func NewUintFromSorted[valueT any](keys []uint, values []valueT, opts ...Option) (*UintMap[valueT], error) {
+	s := NewUint[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// NewUintDescFromSorted returns a skipmap in descending order with the given keys and values,
+// the keys must be strictly in descending order, see NewFromSorted.
+func NewUintDescFromSorted[valueT any](keys []uint, values []valueT, opts ...Option) (*UintMapDesc[valueT], error) {
+	s := NewUintDesc[valueT](opts...)
+	if err := s.initSorted(keys, values); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
@@ -1,6 +1,14 @@
 package skipmap
 
 import (
+	"errors"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"unsafe"
+
 	"github.com/zhangyunhao116/fastrand"
 )
 
@@ -29,3 +37,217 @@ type ordered interface {
 		~float32 | ~float64 | // float
 		~string
 }
+
+// Bounds reports which endpoints are excluded from a bounded range.
+// The zero value means both endpoints are included.
+type Bounds uint8
+
+const (
+	ExcludeLo Bounds = 1 << iota // exclude the lower endpoint
+	ExcludeHi                    // exclude the upper endpoint
+
+	Closed Bounds = 0                     // [lo, hi]
+	Open          = ExcludeLo | ExcludeHi // (lo, hi)
+)
+
+// ErrUnsorted is returned by the constructors such as NewFromSorted
+// if the keys are not strictly ordered by the skipmap's order.
+var ErrUnsorted = errors.New("skipmap: keys are not strictly ordered")
+
+// Entry is a key-value pair, returned by the pagination APIs such as Page.
+type Entry[K, V any] struct {
+	Key   K
+	Value V
+}
+
+// Op is the operation returned by the function passed to Compute.
+type Op uint8
+
+const (
+	OpKeep   Op = iota // keep the current value, or keep the key absent
+	OpStore            // store the new value
+	OpDelete           // delete the key
+)
+
+// call is an in-flight call of the function passed to LoadOrCompute.
+type call[K, V any] struct {
+	key    K
+	value  V
+	loaded bool
+	err    error
+	wg     sync.WaitGroup // done when the call is finished
+
+	// panicked reports whether f panicked or called runtime.Goexit,
+	// the waiters make a new call in this case.
+	panicked bool
+}
+
+func newCall[K, V any](key K) *call[K, V] {
+	c := &call[K, V]{key: key}
+	c.wg.Add(1)
+	return c
+}
+
+// callMap holds the in-flight calls of a skipmap with comparable keys.
+type callMap[K comparable, V any] struct {
+	mu    sync.Mutex
+	calls map[K]*call[K, V] // allocated by the first call
+}
+
+// join returns the in-flight call for the key, or starts a new one if there is none.
+// The started result reports whether the call is started by this caller, who must finish it.
+func (g *callMap[K, V]) join(key K) (c *call[K, V], started bool) {
+	if key != key {
+		return newCall[K, V](key), true // NaN can not be found in the map, the call is not shared
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if c, ok := g.calls[key]; ok {
+		return c, false
+	}
+	if g.calls == nil {
+		g.calls = make(map[K]*call[K, V])
+	}
+	c = newCall[K, V](key)
+	g.calls[key] = c
+	return c, true
+}
+
+// finish removes the call started by join and wakes up its waiters.
+func (g *callMap[K, V]) finish(c *call[K, V]) {
+	if c.key == c.key {
+		g.mu.Lock()
+		delete(g.calls, c.key)
+		g.mu.Unlock()
+	}
+	c.wg.Done()
+}
+
+// callList holds the in-flight calls of a skipmap whose keys are not comparable, sorted by the keys.
+type callList[K, V any] struct {
+	mu    sync.Mutex
+	calls []*call[K, V]
+}
+
+// callLocked calls f with mu locked by the caller. If f panics or calls runtime.Goexit,
+// mu is unlocked before the panic propagates, otherwise the caller still holds it.
+func callLocked(mu *sync.Mutex, f func()) {
+	done := false
+	defer func() {
+		if !done {
+			mu.Unlock()
+		}
+	}()
+	f()
+	done = true
+}
+
+// snapshots is the state of the snapshots of a skipmap created with WithSnapshot.
+//
+// Every write is stamped with the clock, and taking a snapshot increases the clock, so a snapshot
+// taken at ts sees the writes stamped before or at ts. The writers hold mu in read mode, so the
+// clock and the live snapshots do not change during a write.
+type snapshots struct {
+	mu     sync.RWMutex
+	clock  uint64     // starts from 1, 0 means not deleted, see visibleAt
+	live   []uint64   // the timestamps of the live snapshots, in ascending order
+	deadMu sync.Mutex // serializes the updates of the deleted nodes, see retire
+}
+
+func newSnapshots() *snapshots {
+	return &snapshots{clock: 1}
+}
+
+// horizon returns the timestamp of the oldest live snapshot, or math.MaxUint64 if there is none.
+// The versions written before the horizon are not needed, except the last one of them.
+func (sn *snapshots) horizon() uint64 {
+	if len(sn.live) == 0 {
+		return math.MaxUint64
+	}
+	return sn.live[0]
+}
+
+// needed reports whether any live snapshot can see a node created and deleted at the given timestamps.
+func (sn *snapshots) needed(created, deleted uint64) bool {
+	i := sort.Search(len(sn.live), func(i int) bool { return sn.live[i] >= created })
+	return i < len(sn.live) && sn.live[i] < deleted
+}
+
+// visibleAt reports whether a node created and deleted (0 if not) at the given timestamps
+// can be seen by the snapshot taken at ts.
+func visibleAt(created, deleted, ts uint64) bool {
+	return created <= ts && (deleted == 0 || deleted > ts)
+}
+
+// version is a value of a node written at ts, the nodes of a skipmap created with WithSnapshot
+// point to the versions instead of the values. The value must be the first field, so that
+// the pointer can be loaded as a pointer to the value.
+type version[V any] struct {
+	value V
+	ts    uint64
+	prev  unsafe.Pointer // *version[V], the previous version, nil if it is not needed
+}
+
+// newVersion returns a new version written at ts, which replaces the version prev (nil for a new node).
+// The versions older than the last one written before the horizon are dropped.
+func newVersion[V any](value V, prev unsafe.Pointer, ts, horizon uint64) *version[V] {
+	v := &version[V]{value: value, ts: ts}
+	if ts <= horizon {
+		return v // no live snapshot
+	}
+	// The versions written at the same time are replaced by this one.
+	for prev != nil && (*version[V])(prev).ts == ts {
+		prev = atomic.LoadPointer(&(*version[V])(prev).prev)
+	}
+	v.prev = prev
+	for p := prev; p != nil; p = atomic.LoadPointer(&(*version[V])(p).prev) {
+		if (*version[V])(p).ts <= horizon {
+			atomic.StorePointer(&(*version[V])(p).prev, nil)
+			break
+		}
+	}
+	return v
+}
+
+// loadVersion returns the value of the version seen by the snapshot taken at ts.
+func loadVersion[V any](p unsafe.Pointer, ts uint64) (value V, ok bool) {
+	for ; p != nil; p = atomic.LoadPointer(&(*version[V])(p).prev) {
+		if v := (*version[V])(p); v.ts <= ts {
+			return v.value, true
+		}
+	}
+	return value, false
+}
+
+// estimateThreshold is the minimum number of nodes counted in a level to estimate the number
+// of nodes at level 0 from the level below it, see CountRange. The level below has about
+// estimateThreshold/p nodes in the range, so the relative error is about 3%.
+const estimateThreshold = 256
+
+// hasPrefix reports whether the key begins with prefix,
+// the underlying type of K must be string.
+func hasPrefix[K ordered](key, prefix K) bool {
+	return strings.HasPrefix(*(*string)(unsafe.Pointer(&key)), *(*string)(unsafe.Pointer(&prefix)))
+}
+
+// prefixEnd returns the smallest key greater than all the keys with the given prefix,
+// the underlying type of K must be string. The ok result is false if there is no such key,
+// i.e. the prefix is empty or consists of 0xff bytes only.
+func prefixEnd[K ordered](prefix K) (end K, ok bool) {
+	str := *(*string)(unsafe.Pointer(&prefix))
+	for i := len(str) - 1; i >= 0; i-- {
+		if str[i] != 0xff {
+			b := []byte(str[:i+1])
+			b[i]++
+			e := string(b)
+			return *(*K)(unsafe.Pointer(&e)), true
+		}
+	}
+	return end, false
+}
+
+// equalValue reports whether a and b are equal as interfaces,
+// it panics if the dynamic type is not comparable.
+func equalValue[V any](a, b V) bool {
+	return any(a) == any(b)
+}